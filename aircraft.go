@@ -0,0 +1,159 @@
+package main
+
+import (
+	"github.com/sajari/fuzzy"
+	"regexp"
+	"strings"
+)
+
+/*
+ * Aircraft and mission extraction
+ * Aircraft designators (C-17, KC-135) are matched exactly after OCR digit correction. Aircraft and mission names (Globemaster, Patriot Express) are matched with a fuzzy model.
+ */
+
+//Aircraft or mission type listed on slides
+type AircraftType struct {
+	Name      string   //Stored on Flight. ex: C-17
+	Spellings []string //Designators and names as seen on slides
+}
+
+//Known aircraft and mission types
+var aircraftTypes = []AircraftType{
+	AircraftType{Name: "C-17", Spellings: []string{"C-17", "C-17A", "Globemaster"}},
+	AircraftType{Name: "C-5", Spellings: []string{"C-5", "C-5M", "C-5B", "Galaxy", "Super Galaxy"}},
+	AircraftType{Name: "C-130", Spellings: []string{"C-130", "C-130H", "C-130J", "Hercules"}},
+	AircraftType{Name: "KC-135", Spellings: []string{"KC-135", "KC-135R", "Stratotanker"}},
+	AircraftType{Name: "KC-10", Spellings: []string{"KC-10", "KC-10A", "Extender"}},
+	AircraftType{Name: "KC-46", Spellings: []string{"KC-46", "KC-46A", "Pegasus"}},
+	AircraftType{Name: "C-40", Spellings: []string{"C-40", "C-40A", "Clipper"}},
+	AircraftType{Name: "Patriot Express", Spellings: []string{"Patriot Express"}},
+	AircraftType{Name: "Commercial Charter", Spellings: []string{"Commercial", "Charter", "Contract Charter"}}}
+
+//Normalized aircraft spelling -> AircraftType.Name
+var aircraftKeywordMap map[string]string
+
+//Fuzzy model of aircraft and mission names at least FUZZY_MODEL_KEYWORD_MIN_LENGTH long
+var fuzzyModelForAircraft *fuzzy.Model
+
+//Designator with OCR misreads of digits. ex: c-l7 -> c17
+var aircraftDesignatorRegex = regexp.MustCompile("^(kc|c)([0-9lios]{1,3})([a-z]?)$")
+
+//Lowercase and remove spaces, hyphens and periods. ex: "C-17" -> "c17", "Patriot Express" -> "patriotexpress"
+func normalizeAircraftSpelling(spelling string) string {
+	r := strings.NewReplacer(
+		"-", "",
+		".", "",
+		" ", "")
+	normalized := strings.ToLower(r.Replace(spelling))
+
+	//Correct letters read in place of designator digits
+	if match := aircraftDesignatorRegex.FindStringSubmatch(normalized); match != nil {
+		digits := strings.NewReplacer(
+			"l", "1",
+			"i", "1",
+			"o", "0",
+			"s", "5").Replace(match[2])
+		normalized = match[1] + digits + match[3]
+	}
+	return normalized
+}
+
+//Create aircraftKeywordMap and fuzzyModelForAircraft from aircraftTypes
+func createAircraftFuzzyModel() {
+	aircraftKeywordMap = make(map[string]string)
+	fuzzyModelForAircraft = fuzzy.NewModel()
+	fuzzyModelForAircraft.SetThreshold(1)
+	fuzzyModelForAircraft.SetDepth(1)
+
+	for _, a := range aircraftTypes {
+		for _, spelling := range a.Spellings {
+			normalized := normalizeAircraftSpelling(spelling)
+			aircraftKeywordMap[normalized] = a.Name
+
+			//Only train names. Designators must match exactly since C-12 is one edit from C-17.
+			if len(normalized) >= FUZZY_MODEL_KEYWORD_MIN_LENGTH && !aircraftDesignatorRegex.MatchString(normalized) {
+				fuzzyModelForAircraft.TrainWord(normalized)
+			}
+		}
+	}
+}
+
+//Return AircraftType.Name for OCR text. Empty if text is not a known aircraft.
+func findAircraftForText(text string) string {
+	normalized := normalizeAircraftSpelling(text)
+	if name, ok := aircraftKeywordMap[normalized]; ok {
+		return name
+	}
+	if len(normalized) < FUZZY_MODEL_KEYWORD_MIN_LENGTH || fuzzyModelForAircraft == nil {
+		return ""
+	}
+
+	//Closest trained name
+	var closestName string
+	var closestDistance int
+	for _, suggestion := range fuzzyModelForAircraft.Suggestions(normalized, true) {
+		distance := fuzzy.Levenshtein(&normalized, &suggestion)
+		if len(closestName) == 0 || distance < closestDistance {
+			closestName = aircraftKeywordMap[suggestion]
+			closestDistance = distance
+		}
+	}
+	return closestName
+}
+
+//Find aircraft below limitMinY (destination label) in slides. Single OCR words and pairs of adjacent words on a line are matched. ex: "C-17", "C 17", "Patriot Express"
+func findAircraftMarkersOfPhotoNodeSlides(slides []Slide, limitMinY int) (markers []AircraftMarker) {
+	for _, s := range slides {
+		for i, w := range s.Words {
+			if w.BBox.Min.Y < limitMinY {
+				continue
+			}
+
+			//Prefer two word match so "Patriot Express" is not matched as "Patriot" alone
+			if i+1 < len(s.Words) && s.Words[i+1].LineId == w.LineId {
+				if name := findAircraftForText(w.Text + s.Words[i+1].Text); len(name) > 0 {
+					markers = append(markers, AircraftMarker{
+						Aircraft:   name,
+						SharedInfo: SharedInfo{BBox: w.BBox.Union(s.Words[i+1].BBox)}})
+					continue
+				}
+			}
+
+			if name := findAircraftForText(w.Text); len(name) > 0 {
+				markers = append(markers, AircraftMarker{
+					Aircraft:   name,
+					SharedInfo: SharedInfo{BBox: w.BBox}})
+			}
+		}
+	}
+	return
+}
+
+//Set Aircraft of each Destination from the first aircraft found in its row.
+func assignAircraftToDestinations(dests []Destination, markers []AircraftMarker, grid TableGrid, lineHeight int) {
+	for dIndex := range dests {
+		for _, marker := range markers {
+			if isInDestinationRow(grid, dests[dIndex], marker.BBox, lineHeight) {
+				dests[dIndex].Aircraft = marker.Aircraft
+				break
+			}
+		}
+	}
+}
+
+//Return flights with aircraft in aircraft names (case insensitive). All flights if names is empty.
+func filterFlightsByAircraft(flights []Flight, names []string) (filtered []Flight) {
+	if len(names) == 0 {
+		return flights
+	}
+
+	for _, f := range flights {
+		for _, name := range names {
+			if normalizeAircraftSpelling(f.Aircraft) == normalizeAircraftSpelling(name) {
+				filtered = append(filtered, f)
+				break
+			}
+		}
+	}
+	return
+}
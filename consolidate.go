@@ -117,8 +117,8 @@ func deleteDuplicatesFromSAArray(arrayPointer *[]SeatsAvailable) {
 			intersection := destA.BBox.Intersect(destB.BBox)
 			if float64(intersection.Dx())*float64(intersection.Dy()) > float64(smallerArea)*DUPLICATE_AREA_THRESHOLD {
 
-				//If destA has no number found and destB found a number replace
-				if destA.Number == 0 && destB.Number != 0 || destB.Number > destA.Number {
+				//If destB was parsed from more seat text or has more seats replace
+				if destB.isMoreSpecificThan(destA) {
 					dests[i] = dests[j]
 				}
 
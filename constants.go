@@ -1,11 +1,16 @@
 package main
 
+import (
+	"time"
+)
+
 const (
 	DEBUG_EXPORT_TERMINAL_TZ bool = false
 	DEBUG_TERMINAL_SINGLE_FILE bool = false
 	DEBUG_MANUAL_IMAGE_FILE_TARGET bool = false
 	DEBUG_MANUAL_IMAGE_FILE_TARGET_TRAINING_DIRECTORY string = "debug_training"
 	DEBUG_MANUAL_FILENAME string = "jbp.jpg" //relative path of image
+	DEBUG_SAVE_PROCESSED_IMAGES bool = false //Save variant and crop images processed in memory by goImageProcessor
 )
 
 //Terminal info files
@@ -23,6 +28,11 @@ const (
 	GRAPH_EDGE_ALBUMS string = "albums"
 )
 
+//Graph API time format for created_time/updated_time
+const (
+	GRAPH_TIME_LAYOUT string = "2006-01-02T15:04:05-0700"
+)
+
 //Graph API parameter keys
 const (
 	GRAPH_ACCESS_TOKEN_KEY string = "access_token"
@@ -51,6 +61,65 @@ const (
 	GRAPH_ID_KEY   string = "id"
 )
 
+//Photo source names selectable per terminal in terminal file "photoSource" field
+const (
+	PHOTO_SOURCE_GRAPH       string = "graph"
+	PHOTO_SOURCE_DROP_FOLDER string = "dropfolder"
+	PHOTO_SOURCE_DEFAULT     string = PHOTO_SOURCE_GRAPH
+
+	//Number of most recent photos to process for each terminal
+	PHOTO_SOURCE_RECENT_LIMIT int = 4
+
+	//Max age of photo to process
+	PHOTO_SOURCE_MAX_AGE time.Duration = time.Hour * 24
+)
+
+//Drop folder photo source
+const (
+	//Default root directory of drop folder. Images are placed in <root>/<terminal id>/
+	DROP_FOLDER_DIRECTORY string = "drop_folder"
+
+	//Interval between drop folder scans
+	DROP_FOLDER_POLL_INTERVAL time.Duration = time.Second * 10
+
+	//Minimum time since file last modified before file is processed
+	DROP_FOLDER_SETTLE_TIME time.Duration = time.Second * 5
+)
+
+//Photo upload and processing jobs
+const (
+	//Environment variable holding the token required to upload photos. Uploads are disabled if empty.
+	PHOTO_UPLOAD_TOKEN_ENV string = "PHOTO_UPLOAD_TOKEN"
+
+	//Max size of uploaded photo request
+	PHOTO_UPLOAD_MAX_BYTES int64 = 10 << 20
+
+	//Prefix of photo id for uploaded photos
+	PHOTO_UPLOAD_ID_PREFIX string = "upload_"
+
+	//Number of uploaded photo jobs that can wait for processing
+	PHOTO_JOB_QUEUE_LENGTH int = 32
+
+	//Time between checks of photo jobs table for queued uploaded photos
+	PHOTO_JOB_POLL_INTERVAL time.Duration = time.Second * 5
+
+	//Length of random job id in bytes
+	PHOTO_JOB_ID_BYTES int = 16
+
+	//Age after which finished jobs are removed
+	PHOTO_JOB_MAX_AGE time.Duration = time.Hour * 48
+)
+
+//Photo job states
+const (
+	JOB_STATE_QUEUED  string = "queued"
+	JOB_STATE_OCR     string = "ocr"
+	JOB_STATE_PARSING string = "parsing"
+	JOB_STATE_STORED  string = "stored"
+	JOB_STATE_FAILED  string = "failed"
+	JOB_STATE_SKIPPED string = "skipped" //Photo too old to process
+)
+
 //Image storage types
 type SaveImageType int
 
@@ -68,14 +137,64 @@ const (
 	IMAGE_TRAINING_PROCESSED_DIRECTORY_WHITE string = "training_images_processed_white"
 )
 
+//Image processor names selectable with -imageProcessor flag
+const (
+	IMAGE_PROCESSOR_IMAGEMAGICK string = "imagemagick"
+	IMAGE_PROCESSOR_GO          string = "go"
+)
+
+//Go image processor color isolation fuzz (0-1) and output quality. Same values as ImageMagick -fuzz arguments.
+const (
+	IMAGE_PROCESSING_BLACK_FUZZ   float64 = 0.25
+	IMAGE_PROCESSING_WHITE_FUZZ   float64 = 0.35
+	IMAGE_PROCESSING_JPEG_QUALITY int     = 95
+)
+
+//Perspective correction and deskew
+const (
+	RECTIFY_ANALYSIS_MAX_DIMENSION int     = 800  //Largest dimension of downsampled image used to detect screen and skew
+	RECTIFY_MIN_SCREEN_AREA        float64 = 0.25 //Minimum fraction of photo area covered by detected screen
+	RECTIFY_CORNER_TOLERANCE       float64 = 0.02 //Screen edges within this fraction of photo size of horizontal/vertical are not corrected
+	RECTIFY_MAX_SKEW_DEGREES       float64 = 10
+	RECTIFY_SKEW_STEP_DEGREES      float64 = 0.25
+	RECTIFY_MIN_SKEW_DEGREES       float64 = 0.5 //Smaller skew angles are not corrected
+)
+
+//Image variant names used in terminal file "variants" field
+const (
+	IMAGE_VARIANT_ORIGINAL  string = "original"
+	IMAGE_VARIANT_BLACK     string = "black"
+	IMAGE_VARIANT_WHITE     string = "white"
+	IMAGE_VARIANT_GRAYSCALE string = "grayscale"
+	IMAGE_VARIANT_THRESHOLD string = "threshold"
+	IMAGE_VARIANT_INVERT    string = "invert"
+	IMAGE_VARIANT_UPSCALE   string = "upscale2x"
+	IMAGE_VARIANT_RED       string = "red"
+	IMAGE_VARIANT_GREEN     string = "green"
+	IMAGE_VARIANT_BLUE      string = "blue"
+
+	//Directory prefix of registered variants other than original/black/white
+	IMAGE_VARIANT_DIRECTORY_PREFIX string = "training_images_variant_"
+
+	//Adaptive threshold window size (pixels) and offset below window mean (0-255)
+	IMAGE_VARIANT_THRESHOLD_WINDOW int = 31
+	IMAGE_VARIANT_THRESHOLD_OFFSET int = 10
+
+	IMAGE_VARIANT_UPSCALE_FACTOR int = 2
+)
+
 //Image storage suffixes
 const (
 	IMAGE_SUFFIX_CROPPED string = "c"
+	IMAGE_SUFFIX_RAW     string = "raw" //Original photo before rectification
 )
 
 //OCR config constants
 const (
 	FUZZY_MODEL_KEYWORD_MIN_LENGTH int = 5
+
+	//Most words in a location phrase matched against terminal keywords. ex: joint base pearl harbor hickam
+	FUZZY_PHRASE_MAX_WORDS int = 5
 )
 
 //OCR whitelist names
@@ -86,6 +205,16 @@ const (
 	OCR_WHITELIST_SA
 )
 
+//OCR engine names selectable with -ocrEngine flag
+const (
+	OCR_ENGINE_TESSERACT string = "tesseract"
+	OCR_ENGINE_GOSSERACT string = "gosseract" //Only available when built with -tags gosseract
+	OCR_ENGINE_REPLAY    string = "replay"
+
+	//Default directory of recorded OCR fixtures for replay engine
+	OCR_FIXTURE_DIRECTORY string = "ocr_fixtures"
+)
+
 //Tesseract OCR whitelist config filenames
 const (
 	TESS_CONFIGFILE_DIRECTORY       string = "tesseract_configfiles"
@@ -94,9 +223,11 @@ const (
 	TESS_CONFIGFILE_HOCR_FILENAME   string = "_hocr"
 	TESS_CONFIGFILE_EXTENSION       string = "config"
 
-	TESS_OUTPUTBASE            string = "output"
-	TESS_OUTPUT_TXT_EXTENSION  string = "txt"
-	TESS_OUTPUT_HOCR_EXTENSION string = "hocr"
+	TESS_OUTPUT_DIRECTORY_PREFIX string = "spacea_tess" //Prefix of per OCR call temporary output directory
+	TESS_OUTPUTBASE              string = "output"
+	TESS_STDIN                   string = "stdin" //Image name reading image from standard input
+	TESS_OUTPUT_TXT_EXTENSION    string = "txt"
+	TESS_OUTPUT_HOCR_EXTENSION   string = "hocr"
 )
 
 //OCR keywords
@@ -122,14 +253,127 @@ const (
 	//Percentage of area between two bounding boxes to overlap to be considered duplicates.
 	DUPLICATE_AREA_THRESHOLD float64 = 0.5
 
+	//Distances below are in text line heights (median OCR word height) so that the same slide at any resolution parses the same.
+
 	//Horizontal distance to add to left and right of seats bounds when cropping seats text.
-	SEATS_CROP_HORIZONTAL_BUFFER int = 5
+	SEATS_CROP_HORIZONTAL_BUFFER float64 = 0.25
+
+	//Vertical distance to add above and below month bounds when cropping date text.
+	DATE_CROP_VERTICAL_BUFFER float64 = 0.25
 
 	//Furthest vertical distance of destination bounding boxes to be linked.
-	ROLLCALLS_DESTINATION_LINK_VERTICAL_THRESHOLD int = 50
+	ROLLCALLS_DESTINATION_LINK_VERTICAL_THRESHOLD float64 = 2.5
 
 	//Minimum vertical overlap required between seats and rollcall text to be linked. Negative value is positive overlap.
-	ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD int = -5
+	ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD float64 = -0.25
+
+	//Line height as fraction of image height used when no OCR words are available to measure.
+	TEXT_LINE_HEIGHT_DEFAULT_FRACTION float64 = 0.02
+
+	//Furthest vertical distance of time column labels from destination label. Labels may wrap to a second line. ex: "Show\nTime"
+	TIME_COLUMN_LABEL_VERTICAL_THRESHOLD float64 = 1
+)
+
+//Roll call time zone interpretations stored with Flight
+const (
+	ROLLCALL_TIMEZONE_LOCAL string = "L"
+	ROLLCALL_TIMEZONE_ZULU  string = "Z"
+)
+
+//Normalized seat codes stored with Flight
+const (
+	SEAT_CODE_FIRM             string = "firm"
+	SEAT_CODE_TENTATIVE        string = "tentative"
+	SEAT_CODE_FIRM_TENTATIVE   string = "firm_tentative" //ex: 15F/30T
+	SEAT_CODE_SPACE_PERMITTING string = "space_permitting"
+	SEAT_CODE_TBD              string = "tbd"
+	SEAT_CODE_FULL             string = "full"
+	SEAT_CODE_UNSPECIFIED      string = "unspecified" //Seat count without F/T/SP
+)
+
+//Flight confidence score constants. Score and component scores are 0-1.
+const (
+	//Weights of component scores. Sum to 1.
+	FLIGHT_CONFIDENCE_WEIGHT_OCR      float64 = 0.3
+	FLIGHT_CONFIDENCE_WEIGHT_SPELLING float64 = 0.3
+	FLIGHT_CONFIDENCE_WEIGHT_LINK     float64 = 0.25
+	FLIGHT_CONFIDENCE_WEIGHT_DATE     float64 = 0.15
+
+	//Link score falls from 1 for text on the same line to 0 at this vertical distance in text line heights
+	FLIGHT_CONFIDENCE_LINK_DISTANCE_MAX float64 = 3
+)
+
+//Flight status values and cancelled row detection constants
+const (
+	FLIGHT_STATUS_SCHEDULED string = "scheduled"
+	FLIGHT_STATUS_CANCELLED string = "cancelled"
+	FLIGHT_STATUS_DELAYED   string = "delayed"
+	FLIGHT_STATUS_FULL      string = "full"
+
+	//Minimum red channel (0-255) and difference of red to green and blue channels for a pixel to be red
+	FLIGHT_STATUS_RED_MIN       int = 120
+	FLIGHT_STATUS_RED_DOMINANCE int = 60
+
+	//Fraction of text pixels in text bbox that must be red
+	FLIGHT_STATUS_RED_FRACTION float64 = 0.5
+
+	//Vertical band of text bbox height searched for strike through line
+	FLIGHT_STATUS_STRIKE_BAND_TOP    float64 = 0.3
+	FLIGHT_STATUS_STRIKE_BAND_BOTTOM float64 = 0.7
+
+	//Fraction of text bbox width a strike through line must cover
+	FLIGHT_STATUS_STRIKE_FRACTION float64 = 0.9
+)
+
+//Kinds of time columns on slides
+const (
+	TIME_KIND_ROLLCALL  string = "rollcall"
+	TIME_KIND_SHOW      string = "show"
+	TIME_KIND_DEPARTURE string = "departure"
+)
+
+//Slide layout template column names and match tolerance
+const (
+	LAYOUT_COLUMN_DESTINATION string = "destination"
+	LAYOUT_COLUMN_ROLLCALL    string = "rollcall"
+	LAYOUT_COLUMN_SEATS       string = "seats"
+
+	//Distance as fraction of image size that column labels may be outside template columns and still match
+	LAYOUT_MATCH_TOLERANCE float64 = 0.03
+)
+
+//Learned layout profile constants
+const (
+	//Minimum parsed slides a label must be learned from before it replaces a missing label
+	LAYOUT_PROFILE_MIN_SAMPLES int = 3
+	//Maximum weight of previous samples in label average so profile follows slide design changes
+	LAYOUT_PROFILE_MAX_SAMPLES int = 20
+	//Minimum flights with roll call times for a parse to be confident enough to learn from
+	LAYOUT_PROFILE_MIN_FLIGHTS int = 2
+)
+
+//Table grid detection constants
+const (
+	//Minimum luminance difference (0-255) between pixels GRID_EDGE_DISTANCE apart to be a ruling or color band edge.
+	GRID_EDGE_CONTRAST int = 40
+	//Distance in text line heights between pixels compared for edges. At least 1 pixel.
+	GRID_EDGE_DISTANCE float64 = 0.1
+
+	//Minimum length of horizontal ruling as fraction of image width and vertical ruling as fraction of table height.
+	GRID_RULING_MIN_LENGTH_HORIZONTAL float64 = 0.5
+	GRID_RULING_MIN_LENGTH_VERTICAL   float64 = 0.3
+
+	//Maximum gap in text line heights within a ruling.
+	GRID_RULING_MAX_GAP float64 = 0.15
+
+	//Distance in text line heights between edges merged into one boundary.
+	GRID_RULING_MERGE_DISTANCE float64 = 0.3
+
+	//Minimum width of whitespace gap between words as fraction of image width to be a column gutter.
+	GRID_MIN_GUTTER_WIDTH float64 = 0.015
+
+	//Minimum rows in table (including header row) to link by row instead of vertical distance.
+	GRID_MIN_ROWS int = 3
 )
 
 //Storage Database constants
@@ -141,6 +385,9 @@ const (
 	FLIGHTS_72HR_TABLE_INDEX_DEST_RC        string = "hr72_flights_index_dest_rc"
 	FLIGHTS_72HR_TABLE_INDEX_ORIGIN_DEST_RC string = "hr72_flights_index_origin_dest_rc"
 	PHOTOS_REPORTS_TABLE string = "photo_reports"
+	PHOTO_JOBS_TABLE string = "photo_jobs"
+	LAYOUT_PROFILES_TABLE string = "layout_profiles"
+	FLIGHT_LEGS_TABLE string = "flight_legs"
 	FLIGHTS_MAX_SOURCEDATE_AGE_DAYS int = 31
 )
 
@@ -149,6 +396,18 @@ const (
 	REST_ORIGIN_KEY      string = "origin"
 	REST_DESTINATION_KEY string = "destination"
 
+	//REST_STATUS_KEY is comma separated FLIGHT_STATUS_XXX values to select. All statuses if empty.
+	REST_STATUS_KEY string = "status"
+
+	//REST_AIRCRAFT_KEY is comma separated aircraft names to select. ex: C-17,KC-135
+	REST_AIRCRAFT_KEY string = "aircraft"
+
+	//REST_FIRM_SEATS_KEY is minimum firm seat count to select.
+	REST_FIRM_SEATS_KEY string = "firmSeats"
+
+	//REST_MIN_CONFIDENCE_KEY is minimum Flight.Confidence (0-1) to select.
+	REST_MIN_CONFIDENCE_KEY string = "minConfidence"
+
 	//REST_START_TIME_KEY is UTC time
 	//Represented in ISO 1806 / RFC 3339 format 2006-01-02T15:04:05Z
 	REST_START_TIME_KEY string = "startTime"
@@ -160,4 +419,11 @@ const (
 	REST_LOCATION_KEY string = "location"
 	REST_PHOTOSOURCE_KEY string = "photoSource"
 	REST_COMMENT_KEY string = "comment"
+
+	//Photo upload keys
+	REST_TERMINAL_KEY string = "terminal"
+	REST_PHOTO_KEY    string = "photo"
+
+	//Job status path prefix. GET /jobs/{id}
+	REST_JOBS_PATH string = "/jobs/"
 )
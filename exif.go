@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+//EXIF tags used to find the date a photo was taken
+const (
+	EXIF_TAG_DATETIME          uint16 = 0x0132
+	EXIF_TAG_EXIF_IFD_POINTER  uint16 = 0x8769
+	EXIF_TAG_DATETIME_ORIGINAL uint16 = 0x9003
+
+	EXIF_TYPE_ASCII uint16 = 2
+	EXIF_TYPE_LONG  uint16 = 4
+
+	EXIF_DATETIME_LAYOUT string = "2006:01:02 15:04:05"
+)
+
+//Read EXIF DateTimeOriginal (or DateTime if no DateTimeOriginal) from JPEG image data.
+//EXIF dates have no timezone so date is returned in loc.
+//found is false if image is not JPEG or has no EXIF date.
+func readExifDateTime(imageData []byte, loc *time.Location) (date time.Time, found bool, err error) {
+	var tiff []byte
+	if tiff = findExifTIFFBlock(imageData); tiff == nil {
+		return
+	}
+
+	if len(tiff) < 8 {
+		err = errors.New("EXIF TIFF header too short")
+		return
+	}
+
+	//Determine byte order from TIFF header
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		err = errors.New("EXIF TIFF header has unknown byte order")
+		return
+	}
+
+	//Read IFD0 for DateTime and Exif IFD pointer
+	ifd0 := readExifIFD(tiff, order, order.Uint32(tiff[4:8]))
+
+	//Prefer DateTimeOriginal in Exif IFD over DateTime in IFD0
+	var dateText string
+	if exifOffset, ok := ifd0[EXIF_TAG_EXIF_IFD_POINTER]; ok && exifOffset.Type == EXIF_TYPE_LONG {
+		exifIFD := readExifIFD(tiff, order, order.Uint32(exifOffset.Value))
+		if original, ok := exifIFD[EXIF_TAG_DATETIME_ORIGINAL]; ok {
+			dateText = readExifASCII(tiff, order, original)
+		}
+	}
+	if len(dateText) == 0 {
+		if dateTime, ok := ifd0[EXIF_TAG_DATETIME]; ok {
+			dateText = readExifASCII(tiff, order, dateTime)
+		}
+	}
+	if len(dateText) == 0 {
+		return
+	}
+
+	if date, err = time.ParseInLocation(EXIF_DATETIME_LAYOUT, dateText, loc); err != nil {
+		return
+	}
+	found = true
+	return
+}
+
+//Return TIFF block of APP1 Exif segment in JPEG image data. Return nil if not found.
+func findExifTIFFBlock(imageData []byte) (tiff []byte) {
+	//Check JPEG SOI marker
+	if len(imageData) < 4 || imageData[0] != 0xFF || imageData[1] != 0xD8 {
+		return
+	}
+
+	//Walk JPEG segments until APP1 Exif segment or start of scan
+	for i := 2; i+4 <= len(imageData); {
+		if imageData[i] != 0xFF {
+			return
+		}
+		marker := imageData[i+1]
+		//Start of scan. No more metadata segments.
+		if marker == 0xDA {
+			return
+		}
+		segmentLength := int(binary.BigEndian.Uint16(imageData[i+2 : i+4]))
+		segmentEnd := i + 2 + segmentLength
+		if segmentLength < 2 || segmentEnd > len(imageData) {
+			return
+		}
+
+		segment := imageData[i+4 : segmentEnd]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			tiff = segment[6:]
+			return
+		}
+
+		i = segmentEnd
+	}
+	return
+}
+
+//EXIF IFD entry with Value holding the 4 byte value/offset field
+type exifEntry struct {
+	Type  uint16
+	Count uint32
+	Value []byte
+}
+
+//Read IFD entries at offset in TIFF block into map keyed by tag
+func readExifIFD(tiff []byte, order binary.ByteOrder, offset uint32) (entries map[uint16]exifEntry) {
+	entries = make(map[uint16]exifEntry)
+	if int(offset)+2 > len(tiff) {
+		return
+	}
+
+	count := int(order.Uint16(tiff[offset : offset+2]))
+	for n := 0; n < count; n++ {
+		start := int(offset) + 2 + n*12
+		if start+12 > len(tiff) {
+			break
+		}
+		entry := tiff[start : start+12]
+		entries[order.Uint16(entry[0:2])] = exifEntry{
+			Type:  order.Uint16(entry[2:4]),
+			Count: order.Uint32(entry[4:8]),
+			Value: entry[8:12]}
+	}
+	return
+}
+
+//Read ASCII value of IFD entry. Values longer than 4 bytes are stored at offset in Value.
+func readExifASCII(tiff []byte, order binary.ByteOrder, entry exifEntry) (text string) {
+	if entry.Type != EXIF_TYPE_ASCII {
+		return
+	}
+
+	var raw []byte
+	if entry.Count <= 4 {
+		raw = entry.Value[:entry.Count]
+	} else {
+		start := order.Uint32(entry.Value)
+		end := start + entry.Count
+		if end > uint32(len(tiff)) || end < start {
+			return
+		}
+		raw = tiff[start:end]
+	}
+
+	text = strings.TrimRight(string(raw), "\x00 ")
+	return
+}
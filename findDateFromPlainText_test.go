@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFindDateFromPlainText(t *testing.T) {
+	reference := time.Date(2019, time.March, 28, 6, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name           string
+		PlainText      string
+		MonthSpelling  string
+		EstimatedMonth time.Month
+		Reference      time.Time
+		Expected       string //2006-01-02. Empty if no date should be found.
+	}{
+		//Day month year
+		{"DMY", "72 HR FLIGHT SCHEDULE\n28 MAR 2019\n", "MAR", time.March, reference, "2019-03-28"},
+		{"DMY compact 2 digit year", "28MAR19 ROLL CALL", "MAR", time.March, reference, "2019-03-28"},
+		{"DMY ordinal", "27th March, 2019", "March", time.March, reference, "2019-03-27"},
+		{"DMY without year", "FLIGHTS 29 MAR", "MAR", time.March, reference, "2019-03-29"},
+		{"DMY followed by roll call", "28 MAR 0800 RAMSTEIN", "MAR", time.March, reference, "2019-03-28"},
+
+		//Month day year
+		{"MDY", "March 27th 2019", "March", time.March, reference, "2019-03-27"},
+		{"MDY compact", "MAR272019", "MAR", time.March, reference, "2019-03-27"},
+		{"MDY without year", "March 30", "March", time.March, reference, "2019-03-30"},
+
+		//Weekday
+		{"Weekday DMY", "THURSDAY 28 MARCH", "MARCH", time.March, reference, "2019-03-28"},
+		{"Weekday abbreviation DMY", "THU 28 MAR 19", "MAR", time.March, reference, "2019-03-28"},
+		{"Weekday MDY", "Friday, March 29", "March", time.March, reference, "2019-03-29"},
+
+		//Numeric
+		{"Numeric MDY", "SCHEDULE 03/27/2019", "MAR", time.March, reference, "2019-03-27"},
+		{"Numeric DMY", "27/03/19", "MAR", time.March, reference, "2019-03-27"},
+		{"Numeric ISO", "2019-03-27", "MAR", time.March, reference, "2019-03-27"},
+		{"Numeric implausible year", "03/27/2009", "MAR", time.March, reference, ""},
+
+		//Year rollover and previous month
+		{"December on January photo", "31 DEC", "DEC", time.December, time.Date(2019, time.January, 1, 6, 0, 0, 0, time.UTC), "2018-12-31"},
+		{"January on December photo", "01 JAN", "JAN", time.January, time.Date(2018, time.December, 31, 6, 0, 0, 0, time.UTC), "2019-01-01"},
+		{"Previous month", "SUN 31 MAR", "MAR", time.March, time.Date(2019, time.April, 1, 6, 0, 0, 0, time.UTC), "2019-03-31"},
+
+		//Invalid dates
+		{"Day over 31", "45 MAR", "MAR", time.March, reference, ""},
+		{"Day not in month", "31 FEB", "FEB", time.February, reference, ""},
+		{"No date", "ROLL CALL DESTINATION SEATS", "MAR", time.March, reference, ""},
+
+		//Flight row text is not a date
+		{"Roll call before month", "1015 MARCH ARB 15F", "MAR", time.March, reference, ""},
+		{"Seats before month prefix", "15T Mayport", "MAY", time.May, reference, ""},
+		{"Seats before word starting with month", "20 Marine Corps", "MAR", time.March, reference, ""},
+		{"Seats and month on separate lines", "0800 RAMSTEIN 15\nMARCH ARB", "MAR", time.March, reference, ""}}
+
+	for _, test := range tests {
+		date, err := findDateFromPlainText(test.PlainText, test.MonthSpelling, test.EstimatedMonth, test.Reference, time.UTC)
+		if err != nil {
+			t.Errorf("%v: %v", test.Name, err)
+			continue
+		}
+
+		var found string
+		if !date.IsZero() {
+			found = date.Format("2006-01-02")
+		}
+		if found != test.Expected {
+			t.Errorf("%v: %q found %q, expected %q", test.Name, test.PlainText, found, test.Expected)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+)
+
+//Airport codes should only match as whole uppercase OCR words outside date headers.
+func TestFindTerminalKeywordsInPlainTextCodes(t *testing.T) {
+	previousCodeMap, previousKeywordMap, previousModels := locationCodeMap, locationKeywordMap, fuzzyModelByDepth
+	locationCodeMap = map[string]string{"THU": "Thule AB, Greenland", "RMS": "Ramstein AB, Germany"}
+	locationKeywordMap = map[string]string{"thu": "Thule AB, Greenland", "rms": "Ramstein AB, Germany"}
+	fuzzyModelByDepth = nil
+	defer func() { locationCodeMap, locationKeywordMap, fuzzyModelByDepth = previousCodeMap, previousKeywordMap, previousModels }()
+
+	tests := []struct {
+		Name      string
+		PlainText string
+		Expected  []string //Codes expected to be found
+	}{
+		{"Code", "1015 RMS 15F", []string{"rms"}},
+		{"Code with punctuation", "1015 RMS, 15F", []string{"rms"}},
+		{"Lowercase code", "1015 rms 15F", nil},
+		{"Code in longer OCR word", "1015 RMS-THU 15F", nil},
+		{"Weekday abbreviation date header", "THU 28 MAR", nil},
+		{"Weekday date header", "THU, MARCH 28", nil},
+		{"Weekday date header without month", "THU 28", nil},
+		{"Code below date header", "THU 28 MAR\n0800 THU 10T", []string{"thu"}},
+		{"Code without date", "THU SEATS", []string{"thu"}}}
+
+	for _, test := range tests {
+		found, err := findTerminalKeywordsInPlainText(test.PlainText)
+		if err != nil {
+			t.Errorf("%v: %v", test.Name, err)
+			continue
+		}
+		if len(found) != len(test.Expected) {
+			t.Errorf("%v: %q found %v, expected %v", test.Name, test.PlainText, found, test.Expected)
+			continue
+		}
+		for _, code := range test.Expected {
+			if _, ok := found[code]; !ok {
+				t.Errorf("%v: %q found %v, expected %v", test.Name, test.PlainText, found, test.Expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"image"
+)
+
+/*
+ * Per flight confidence scoring
+ * Flight.Confidence is a weighted average of OCR word confidence, destination spelling distance, roll call and seats link distance, and date certainty.
+ */
+
+//Return mean OCR word confidence (0-100) of words in slides mostly inside bbox. ok is false if no words are inside bbox.
+func ocrConfidenceOfRect(slides []Slide, bbox image.Rectangle) (confidence float64, ok bool) {
+	var sum, count int
+	for _, s := range slides {
+		for _, w := range s.Words {
+			intersection := w.BBox.Intersect(bbox)
+			if float64(intersection.Dx()*intersection.Dy()) <= float64(w.BBox.Dx()*w.BBox.Dy())*DUPLICATE_AREA_THRESHOLD {
+				continue
+			}
+			sum += w.Confidence
+			count++
+		}
+	}
+	if count == 0 {
+		return
+	}
+	return float64(sum) / float64(count), true
+}
+
+//Return 1 for text on the same line as target falling to 0 at FLIGHT_CONFIDENCE_LINK_DISTANCE_MAX line heights away.
+func linkScoreForRects(bbox image.Rectangle, target image.Rectangle, lineHeight int) float64 {
+	maxDistance := lineHeightsToPixels(lineHeight, FLIGHT_CONFIDENCE_LINK_DISTANCE_MAX)
+	if maxDistance <= 0 {
+		return 0
+	}
+	return clampScore(1 - float64(getVerticalDistance(bbox, target))/float64(maxDistance))
+}
+
+//Clamp score to 0-1
+func clampScore(score float64) float64 {
+	if score < 0 {
+		return 0
+	}
+	if score > 1 {
+		return 1
+	}
+	return score
+}
+
+//Set Flight.Provenance and Flight.Confidence from the Destination, linked RollCall and linked SeatsAvailable the flight was built from.
+//dateKnown is true if the roll call date was found on the slide.
+func (f *Flight) scoreConfidence(slides []Slide, d Destination, dateKnown bool, lineHeight int) {
+	p := FlightProvenance{
+		DestinationSpelling: d.Spelling,
+		DestinationBBox:     d.BBox}
+
+	//OCR confidence of destination and roll call text
+	ocrRects := []image.Rectangle{d.BBox}
+	if d.LinkedRollCall != nil {
+		p.RollCallSpelling = (*d.LinkedRollCall).Spelling
+		p.RollCallBBox = (*d.LinkedRollCall).BBox
+		ocrRects = append(ocrRects, p.RollCallBBox)
+	}
+	var ocrSum float64
+	for _, r := range ocrRects {
+		if confidence, ok := ocrConfidenceOfRect(slides, r); ok {
+			ocrSum += confidence / 100
+		}
+	}
+	p.OCRScore = ocrSum / float64(len(ocrRects))
+
+	//Fuzzy match edits relative to spelling length
+	if len(d.Spelling) > 0 {
+		p.SpellingScore = clampScore(1 - float64(d.SpellingDistance)/float64(len(d.Spelling)))
+	}
+
+	//Roll call link distance, averaged with seats link distance if seats were found with a bbox
+	if d.LinkedSeatsAvailable != nil {
+		p.SeatsSpelling = (*d.LinkedSeatsAvailable).Spelling
+		p.SeatsBBox = (*d.LinkedSeatsAvailable).BBox
+	}
+	if d.LinkedRollCall != nil {
+		p.LinkScore = linkScoreForRects(p.RollCallBBox, d.BBox, lineHeight)
+		if !p.SeatsBBox.Empty() {
+			p.LinkScore = (p.LinkScore + linkScoreForRects(p.SeatsBBox, d.BBox, lineHeight)) / 2
+		}
+	}
+
+	if dateKnown {
+		p.DateScore = 1
+	}
+
+	f.Confidence = FLIGHT_CONFIDENCE_WEIGHT_OCR*p.OCRScore +
+		FLIGHT_CONFIDENCE_WEIGHT_SPELLING*p.SpellingScore +
+		FLIGHT_CONFIDENCE_WEIGHT_LINK*p.LinkScore +
+		FLIGHT_CONFIDENCE_WEIGHT_DATE*p.DateScore
+
+	//Report text bboxes in original photo coordinates if photo was rectified
+	transform := slides[0].Transform
+	p.DestinationBBox = transform.mapRectToOriginal(p.DestinationBBox)
+	p.RollCallBBox = transform.mapRectToOriginal(p.RollCallBBox)
+	p.SeatsBBox = transform.mapRectToOriginal(p.SeatsBBox)
+	f.Provenance = p
+}
+
+//Return flights with Confidence at least minConfidence.
+func filterFlightsByConfidence(flights []Flight, minConfidence float64) (filtered []Flight) {
+	for _, f := range flights {
+		if f.Confidence >= minConfidence {
+			filtered = append(filtered, f)
+		}
+	}
+	return
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sort"
+)
+
+/*
+ * Multi stop itinerary parsing
+ * Destinations listed on the same text line of a Grouping are stops in reading order. ex: RAMSTEIN - ROTA - NORFOLK
+ * Every stop is still stored as a flight so it can be searched by destination. Each flight has the legs leading to its stop.
+ */
+
+//Return ordered stop sequences of Destinations in Grouping as indexes into Grouping.Destinations.
+//Destinations on the same text line are sorted left to right. Lines with a single Destination are not stop sequences.
+func findStopSequencesOfGrouping(g Grouping) (sequences [][]int) {
+	assigned := make([]bool, len(g.Destinations))
+
+	for i := range g.Destinations {
+		if assigned[i] {
+			continue
+		}
+
+		sequence := []int{i}
+		assigned[i] = true
+		for j := i + 1; j < len(g.Destinations); j++ {
+			if !assigned[j] && sameHorizontalLine(g.Destinations[i].BBox, g.Destinations[j].BBox) {
+				sequence = append(sequence, j)
+				assigned[j] = true
+			}
+		}
+		if len(sequence) < 2 {
+			continue
+		}
+
+		sort.Slice(sequence, func(a, b int) bool {
+			return g.Destinations[sequence[a]].BBox.Min.X < g.Destinations[sequence[b]].BBox.Min.X
+		})
+		sequences = append(sequences, sequence)
+	}
+	return
+}
+
+//Return legs of each Destination in Grouping, in the same order as Grouping.Destinations.
+//Legs go from origin through earlier stops on the line to the Destination. nil for Destinations that are not part of a stop sequence.
+//Stops matching origin or the previous stop are skipped. ex: origin Ramstein listed first on the line
+func findLegsOfGroupingDestinations(origin string, g Grouping) (legs [][]FlightLeg) {
+	legs = make([][]FlightLeg, len(g.Destinations))
+
+	for _, sequence := range findStopSequencesOfGrouping(g) {
+		var sequenceLegs []FlightLeg
+		previous := origin
+		for _, dIndex := range sequence {
+			stop := g.Destinations[dIndex].TerminalTitle
+			if stop != previous {
+				sequenceLegs = append(sequenceLegs, FlightLeg{
+					Origin:      previous,
+					Destination: stop})
+				previous = stop
+			}
+
+			//Flight to a stop only has the legs up to that stop. Single leg is a nonstop flight.
+			if len(sequenceLegs) > 1 {
+				legs[dIndex] = append([]FlightLeg(nil), sequenceLegs...)
+			}
+		}
+	}
+	return
+}
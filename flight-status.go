@@ -0,0 +1,194 @@
+package main
+
+import (
+	"image"
+	"sort"
+	"strings"
+)
+
+/*
+ * Cancelled, delayed and full flight detection
+ * Status comes from status words in a flight row, red row text, or destination text struck through.
+ */
+
+//Return FLIGHT_STATUS_XXX for OCR token. Empty if token is not a status word.
+func flightStatusForToken(token string) string {
+	switch {
+	case token == "canx", token == "cnx", token == "cncl", strings.HasPrefix(token, "cancel"):
+		return FLIGHT_STATUS_CANCELLED
+	case token == "dly", strings.HasPrefix(token, "delay"):
+		return FLIGHT_STATUS_DELAYED
+	case token == "full":
+		return FLIGHT_STATUS_FULL
+	}
+	return ""
+}
+
+//Find status words below limitMinY (destination label) in slides. ex: "CANX", "CANCELLED", "DELAYED", "FULL"
+func findFlightStatusMarkersOfPhotoNodeSlides(slides []Slide, limitMinY int) (markers []FlightStatusMarker) {
+	for _, s := range slides {
+		for _, w := range s.Words {
+			if w.BBox.Min.Y < limitMinY {
+				continue
+			}
+			for _, token := range strings.FieldsFunc(strings.ToLower(w.Text), isOCRWordSeparator) {
+				if status := flightStatusForToken(token); len(status) > 0 {
+					markers = append(markers, FlightStatusMarker{
+						Status:     status,
+						SharedInfo: SharedInfo{BBox: w.BBox}})
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+//Set Status of each Destination from status words in its row, then from red or struck through row text.
+func detectFlightStatusOfDestinations(slides []Slide, dests []Destination, markers []FlightStatusMarker, grid TableGrid, lineHeight int) (err error) {
+	for dIndex := range dests {
+		for _, marker := range markers {
+			if !isInDestinationRow(grid, dests[dIndex], marker.BBox, lineHeight) {
+				continue
+			}
+			//Cancelled overrides delayed or full in the same row
+			if len(dests[dIndex].Status) == 0 || marker.Status == FLIGHT_STATUS_CANCELLED {
+				dests[dIndex].Status = marker.Status
+			}
+		}
+	}
+
+	//Red or struck through text marks cancelled flights
+	originalSlide := slides[0]
+	originalSlide.SaveType = SAVE_IMAGE_TRAINING
+	originalSlide.Suffix = ""
+
+	var img image.Image
+	if img, err = readImageFile(photoPath(originalSlide)); err != nil {
+		return
+	}
+	gray := grayscaleImage(img)
+
+	//Lines at grid row boundaries are table rulings, not strike through lines
+	rulingDistance := lineHeightsToPixels(lineHeight, GRID_RULING_MERGE_DISTANCE)
+
+	for dIndex := range dests {
+		if len(dests[dIndex].Status) > 0 {
+			continue
+		}
+
+		rowRects := []image.Rectangle{dests[dIndex].BBox}
+		if dests[dIndex].LinkedRollCall != nil {
+			rowRects = append(rowRects, (*dests[dIndex].LinkedRollCall).BBox)
+		}
+		for _, r := range rowRects {
+			if isRedText(img, gray, r) || isStruckThroughText(gray, r, grid.Rows, rulingDistance) {
+				dests[dIndex].Status = FLIGHT_STATUS_CANCELLED
+				break
+			}
+		}
+	}
+
+	return
+}
+
+//Return true if text in bbox is mostly red. gray is the grayscale of img.
+//Only text pixels that differ from the local background are tested so a red row band with dark or light text is not red text.
+func isRedText(img image.Image, gray *image.Gray, bbox image.Rectangle) bool {
+	bbox = bbox.Intersect(gray.Bounds())
+	if bbox.Empty() {
+		return false
+	}
+
+	//Text pixels differ from median (background) luminance of bbox
+	background := medianLuminance(gray, bbox)
+	origin := img.Bounds().Min
+
+	var ink, redInk int
+	for y := bbox.Min.Y; y < bbox.Max.Y; y++ {
+		for x := bbox.Min.X; x < bbox.Max.X; x++ {
+			if absInt(int(gray.GrayAt(x, y).Y)-background) <= GRID_EDGE_CONTRAST {
+				continue
+			}
+			ink++
+
+			r, g, b, _ := img.At(origin.X+x, origin.Y+y).RGBA()
+			if int(r>>8) >= FLIGHT_STATUS_RED_MIN && int(r>>8)-int(g>>8) >= FLIGHT_STATUS_RED_DOMINANCE && int(r>>8)-int(b>>8) >= FLIGHT_STATUS_RED_DOMINANCE {
+				redInk++
+			}
+		}
+	}
+
+	return ink > 0 && float64(redInk) >= FLIGHT_STATUS_RED_FRACTION*float64(ink)
+}
+
+//Return true if a line crosses the middle of text in bbox. Some row in the middle band must be text colored across nearly the full width.
+//Rows within rulingDistance of a horizontal ruling in rulings (grid row boundaries) are table lines and skipped.
+func isStruckThroughText(gray *image.Gray, bbox image.Rectangle, rulings []int, rulingDistance int) bool {
+	bbox = bbox.Intersect(gray.Bounds())
+	if bbox.Dx() == 0 || bbox.Dy() < 3 {
+		return false
+	}
+	background := medianLuminance(gray, bbox)
+
+	top := bbox.Min.Y + int(FLIGHT_STATUS_STRIKE_BAND_TOP*float64(bbox.Dy()))
+	bottom := bbox.Min.Y + int(FLIGHT_STATUS_STRIKE_BAND_BOTTOM*float64(bbox.Dy()))
+	for y := top; y <= bottom && y < bbox.Max.Y; y++ {
+		if isNearRuling(y, rulings, rulingDistance) {
+			continue
+		}
+
+		var ink int
+		for x := bbox.Min.X; x < bbox.Max.X; x++ {
+			if absInt(int(gray.GrayAt(x, y).Y)-background) > GRID_EDGE_CONTRAST {
+				ink++
+			}
+		}
+		if float64(ink) >= FLIGHT_STATUS_STRIKE_FRACTION*float64(bbox.Dx()) {
+			return true
+		}
+	}
+	return false
+}
+
+//Return true if y is within distance of a ruling Y coordinate
+func isNearRuling(y int, rulings []int, distance int) bool {
+	for _, ruling := range rulings {
+		if absInt(y-ruling) <= distance {
+			return true
+		}
+	}
+	return false
+}
+
+//Return median luminance of gray pixels in bbox
+func medianLuminance(gray *image.Gray, bbox image.Rectangle) int {
+	var values []int
+	for y := bbox.Min.Y; y < bbox.Max.Y; y++ {
+		for x := bbox.Min.X; x < bbox.Max.X; x++ {
+			values = append(values, int(gray.GrayAt(x, y).Y))
+		}
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Ints(values)
+	return values[len(values)/2]
+}
+
+//Return flights with status in statuses. All flights if statuses is empty.
+func filterFlightsByStatus(flights []Flight, statuses []string) (filtered []Flight) {
+	if len(statuses) == 0 {
+		return flights
+	}
+
+	for _, f := range flights {
+		for _, status := range statuses {
+			if f.Status == status {
+				filtered = append(filtered, f)
+				break
+			}
+		}
+	}
+	return
+}
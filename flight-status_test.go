@@ -0,0 +1,53 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+//Return image of a text bbox with background and block letters of text color. Letters cover the middle of the bbox height.
+func flightStatusTestImage(background color.Color, text color.Color) (img *image.RGBA, bbox image.Rectangle) {
+	img = image.NewRGBA(image.Rect(0, 0, 100, 20))
+	draw.Draw(img, img.Bounds(), &image.Uniform{background}, image.ZP, draw.Src)
+	for x := 10; x < 90; x += 10 {
+		draw.Draw(img, image.Rect(x, 4, x+4, 16), &image.Uniform{text}, image.ZP, draw.Src)
+	}
+	return img, image.Rect(5, 2, 95, 18)
+}
+
+func TestIsRedText(t *testing.T) {
+	red := color.RGBA{220, 30, 30, 255}
+	tests := []struct {
+		Name       string
+		Background color.Color
+		Text       color.Color
+		Expected   bool
+	}{
+		{"Red text", color.White, red, true},
+		{"Black text", color.White, color.Black, false},
+		{"Red background row with black text", red, color.Black, false},
+		{"Red background row with white text", red, color.White, false}}
+
+	for _, test := range tests {
+		img, bbox := flightStatusTestImage(test.Background, test.Text)
+		if found := isRedText(img, grayscaleImage(img), bbox); found != test.Expected {
+			t.Errorf("%v: found %v, expected %v", test.Name, found, test.Expected)
+		}
+	}
+}
+
+func TestIsStruckThroughText(t *testing.T) {
+	img, bbox := flightStatusTestImage(color.White, color.Black)
+	//Line across the middle of the text
+	draw.Draw(img, image.Rect(0, 10, 100, 11), &image.Uniform{color.Black}, image.ZP, draw.Src)
+	gray := grayscaleImage(img)
+
+	if !isStruckThroughText(gray, bbox, nil, 1) {
+		t.Error("Line across text without ruling not struck through")
+	}
+	if isStruckThroughText(gray, bbox, []int{0, 10, 20}, 1) {
+		t.Error("Ruling line at grid row boundary read as strike through")
+	}
+}
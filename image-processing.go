@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//Image processor creating processed color variants and crops of slide images.
+//Processors working in memory set Slide.Image. Others save images at photoPath and leave Slide.Image nil.
+type ImageProcessor interface {
+	//Create processed image for sReference.SaveType from image of source slide.
+	ColorProcess(source Slide, sReference *Slide) (err error)
+
+	//Crop image of sReference to cropRect. Return cropped slide with IMAGE_SUFFIX_CROPPED suffix.
+	CropProcess(sReference Slide, cropRect image.Rectangle) (cropped Slide, err error)
+}
+
+//ImageProcessor used for slide processing. Set with -imageProcessor flag.
+var imageProcessor ImageProcessor = imageMagickProcessor{}
+
+//ImageProcessor implementations keyed by -imageProcessor flag name.
+var imageProcessors = map[string]ImageProcessor{
+	IMAGE_PROCESSOR_IMAGEMAGICK: imageMagickProcessor{},
+	IMAGE_PROCESSOR_GO:          goImageProcessor{},
+}
+
+//Return ImageProcessor for name.
+func imageProcessorForName(name string) (processor ImageProcessor, err error) {
+	var ok bool
+	if processor, ok = imageProcessors[name]; !ok {
+		err = fmt.Errorf("Unknown image processor %v.", name)
+	}
+	return
+}
+
+//Create processed color image for slide with imageProcessor.
+func runImageColorProcess(source Slide, sReference *Slide) (err error) {
+	err = imageProcessor.ColorProcess(source, sReference)
+	return
+}
+
+//Crop vertically to form horizonal row image with imageProcessor. Coordinates are in original image coordinates.
+func runImageDateCropVerticalProcess(sReference Slide, cropVerticalOffset int, cropHeight int) (cropped Slide, err error) {
+	var im image.Config
+	if im, err = sReference.getImageConfig(); err != nil {
+		return
+	}
+
+	cropped, err = imageProcessor.CropProcess(sReference, scaleRect(image.Rect(0, cropVerticalOffset, im.Width, cropVerticalOffset+cropHeight), sReference.imageScale()))
+	return
+}
+
+//Crop horizontal starting top at cropVerticalOffset to form vertical column image with imageProcessor. Coordinates are in original image coordinates.
+func runImageDateCropHorizontalProcess(sReference Slide, cropHorizontalMinMax image.Point, cropVerticalOffset int) (cropped Slide, err error) {
+	var im image.Config
+	if im, err = sReference.getImageConfig(); err != nil {
+		return
+	}
+
+	cropped, err = imageProcessor.CropProcess(sReference, scaleRect(image.Rect(cropHorizontalMinMax.X, cropVerticalOffset, cropHorizontalMinMax.Y, im.Height), sReference.imageScale()))
+	return
+}
+
+/*
+ * ImageMagick ImageProcessor
+ */
+
+//ImageProcessor running ImageMagick convert command.
+type imageMagickProcessor struct{}
+
+//ImageMagick is only used for black and white variants. Other variants use goImageProcessor filters.
+func (imageMagickProcessor) ColorProcess(source Slide, sReference *Slide) (err error) {
+	switch sReference.SaveType {
+	case SAVE_IMAGE_TRAINING_PROCESSED_BLACK, SAVE_IMAGE_TRAINING_PROCESSED_WHITE:
+		sReference.Image = nil
+		err = runImageMagickColorProcess(source.SaveType, *sReference)
+	default:
+		err = goImageProcessor{}.ColorProcess(source, sReference)
+	}
+	return
+}
+
+//Images of variants created in memory by goImageProcessor are also cropped in memory.
+func (imageMagickProcessor) CropProcess(sReference Slide, cropRect image.Rectangle) (cropped Slide, err error) {
+	if sReference.Image != nil {
+		cropped, err = goImageProcessor{}.CropProcess(sReference, cropRect)
+		return
+	}
+
+	if err = runImageMagickCropProcess(sReference, []int{cropRect.Dx(), cropRect.Dy(), cropRect.Min.X, cropRect.Min.Y}); err != nil {
+		return
+	}
+	cropped = sReference
+	cropped.Suffix = IMAGE_SUFFIX_CROPPED
+	return
+}
+
+/*
+ * Pure Go ImageProcessor
+ * Reproduces the ImageMagick color isolation in memory so that ImageMagick does not need to be installed.
+ */
+
+//ImageProcessor operating on image.Image in memory. Images are only saved when DEBUG_SAVE_PROCESSED_IMAGES is true.
+type goImageProcessor struct{}
+
+func (goImageProcessor) ColorProcess(source Slide, sReference *Slide) (err error) {
+	var original image.Image
+	if original, err = source.image(); err != nil {
+		return
+	}
+
+	var variant ImageVariant
+	if variant, err = imageVariantForSaveType(sReference.SaveType); err != nil {
+		return
+	}
+	if variant.Filter == nil {
+		err = fmt.Errorf("No filter for image variant %v", variant.Name)
+		return
+	}
+
+	sReference.Image = variant.Filter(original)
+	err = saveProcessedImageForDebug(*sReference)
+	return
+}
+
+func (goImageProcessor) CropProcess(sReference Slide, cropRect image.Rectangle) (cropped Slide, err error) {
+	var original image.Image
+	if original, err = sReference.image(); err != nil {
+		return
+	}
+
+	cropped = sReference
+	cropped.Suffix = IMAGE_SUFFIX_CROPPED
+	cropped.Image = cropImage(original, cropRect)
+	err = saveProcessedImageForDebug(cropped)
+	return
+}
+
+//Return image of slide kept in memory, or decode image saved at photoPath.
+func (sReference Slide) image() (img image.Image, err error) {
+	if sReference.Image != nil {
+		img = sReference.Image
+		return
+	}
+	img, err = readImageFile(photoPath(sReference))
+	return
+}
+
+//Save image of slide processed in memory at photoPath if DEBUG_SAVE_PROCESSED_IMAGES is true.
+func saveProcessedImageForDebug(sReference Slide) (err error) {
+	if !DEBUG_SAVE_PROCESSED_IMAGES || sReference.Image == nil {
+		return
+	}
+	err = writeImageFile(sReference.Image, photoPath(sReference))
+	return
+}
+
+//Return true if c is within fuzz (0-1) of target. Distance is RGB euclidean distance normalized to 0-1 like ImageMagick -fuzz.
+func colorWithinFuzz(c color.Color, target color.Color, fuzz float64) bool {
+	r1, g1, b1, _ := c.RGBA()
+	r2, g2, b2, _ := target.RGBA()
+
+	dr := float64(r1) - float64(r2)
+	dg := float64(g1) - float64(g2)
+	db := float64(b1) - float64(b2)
+
+	distance := math.Sqrt(dr*dr+dg*dg+db*db) / (math.Sqrt(3) * 0xffff)
+	return distance <= fuzz
+}
+
+//Keep pixels within fuzz of black and replace all other pixels with white so that only black text remains.
+//Matches SAVE_IMAGE_TRAINING_PROCESSED_BLACK ImageMagick process isolating black text.
+func isolateBlackText(src image.Image, fuzz float64) (dst *image.RGBA) {
+	bounds := src.Bounds()
+	dst = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := src.At(x, y)
+			if !colorWithinFuzz(c, color.Black, fuzz) {
+				c = color.White
+			}
+			dst.Set(x-bounds.Min.X, y-bounds.Min.Y, c)
+		}
+	}
+	return
+}
+
+//Replace pixels not within fuzz of white with black then negate so that white text becomes black text on white.
+//Same result as SAVE_IMAGE_TRAINING_PROCESSED_WHITE ImageMagick process.
+func isolateWhiteText(src image.Image, fuzz float64) (dst *image.RGBA) {
+	bounds := src.Bounds()
+	dst = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := src.At(x, y)
+			if !colorWithinFuzz(c, color.White, fuzz) {
+				dst.Set(x-bounds.Min.X, y-bounds.Min.Y, color.White)
+				continue
+			}
+
+			r, g, b, _ := c.RGBA()
+			dst.Set(x-bounds.Min.X, y-bounds.Min.Y, color.RGBA64{
+				R: uint16(0xffff - r),
+				G: uint16(0xffff - g),
+				B: uint16(0xffff - b),
+				A: 0xffff})
+		}
+	}
+	return
+}
+
+//Crop src to cropRect clipped to src bounds. Returned image origin is the top left of cropRect like ImageMagick +repage.
+func cropImage(src image.Image, cropRect image.Rectangle) (dst *image.RGBA) {
+	cropRect = cropRect.Intersect(src.Bounds())
+	dst = image.NewRGBA(image.Rect(0, 0, cropRect.Dx(), cropRect.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, cropRect.Min, draw.Src)
+	return
+}
+
+//Decode image file at path.
+func readImageFile(path string) (img image.Image, err error) {
+	var reader *os.File
+	if reader, err = os.Open(path); err != nil {
+		return
+	}
+	defer reader.Close()
+
+	img, _, err = image.Decode(reader)
+	return
+}
+
+//Encode img as PNG. Used to pass images in memory to OCR without lossy compression.
+func encodePNG(img image.Image) (data []byte, err error) {
+	var buffer bytes.Buffer
+	if err = png.Encode(&buffer, img); err != nil {
+		return
+	}
+	data = buffer.Bytes()
+	return
+}
+
+//Encode img to path with encoder chosen by file extension.
+func writeImageFile(img image.Image, path string) (err error) {
+	var writer *os.File
+	if writer, err = os.Create(path); err != nil {
+		return
+	}
+	defer func() {
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg":
+		err = jpeg.Encode(writer, img, &jpeg.Options{Quality: IMAGE_PROCESSING_JPEG_QUALITY})
+	case ".png":
+		err = png.Encode(writer, img)
+	case ".gif":
+		err = gif.Encode(writer, img, nil)
+	default:
+		err = fmt.Errorf("Unknown image extension for %v", path)
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+//goImageProcessor variants and crops should stay in memory without writing image files.
+func TestGoImageProcessorInMemory(t *testing.T) {
+	workingDirectory, err := ioutil.TempDir("", "spacea_image_processing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workingDirectory)
+	previousDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(workingDirectory); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(previousDirectory)
+
+	//Black text on gray background
+	original := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			original.Set(x, y, color.Gray{Y: 0x80})
+		}
+	}
+	for x := 10; x < 30; x++ {
+		original.Set(x, 10, color.Black)
+	}
+
+	source := Slide{
+		SaveType:  SAVE_IMAGE_TRAINING,
+		Extension: "png",
+		Terminal:  Terminal{Title: "Test Terminal"},
+		FBNodeId:  "photo",
+		Image:     original}
+	variant := source
+	variant.SaveType = SAVE_IMAGE_TRAINING_PROCESSED_BLACK
+	variant.Image = nil
+
+	processor := goImageProcessor{}
+	if err = processor.ColorProcess(source, &variant); err != nil {
+		t.Fatal(err)
+	}
+	if variant.Image == nil {
+		t.Fatal("Variant image not kept in memory")
+	}
+	if r, g, b, _ := variant.Image.At(0, 0).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("Background of black text variant is %v, expected white", variant.Image.At(0, 0))
+	}
+
+	var cropped Slide
+	if cropped, err = processor.CropProcess(variant, image.Rect(10, 5, 30, 15)); err != nil {
+		t.Fatal(err)
+	}
+	if cropped.Suffix != IMAGE_SUFFIX_CROPPED {
+		t.Errorf("Cropped slide suffix is %q, expected %q", cropped.Suffix, IMAGE_SUFFIX_CROPPED)
+	}
+	if size := cropped.Image.Bounds().Size(); size != image.Pt(20, 10) {
+		t.Errorf("Cropped image size is %v, expected 20x10", size)
+	}
+
+	for _, s := range []Slide{variant, cropped} {
+		if _, err = os.Stat(photoPath(s)); !os.IsNotExist(err) {
+			t.Errorf("Image file %v written for slide processed in memory", photoPath(s))
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+/*
+ * Perspective correction and deskew of phone captured schedule photos
+ * Photos of a TV screen or printed board are rectified before OCR so that schedule rows are horizontal.
+ */
+
+//Projective transform mapping rectified image coordinates to original photo coordinates
+type ImageTransform struct {
+	Homography    [9]float64     //Row major 3x3 matrix. Identity if photo was not rectified.
+	Quadrilateral [4]image.Point //Screen corners found in original photo (top left, top right, bottom right, bottom left). Zero if no screen found.
+	SkewAngle     float64        //Text angle in degrees removed by deskew
+}
+
+//Return identity ImageTransform
+func identityImageTransform() ImageTransform {
+	return ImageTransform{Homography: [9]float64{1, 0, 0, 0, 1, 0, 0, 0, 1}}
+}
+
+//Return true if transform does not change coordinates. Zero value ImageTransform of slides not rectified is identity.
+func (t ImageTransform) isIdentity() bool {
+	return t.Homography == identityImageTransform().Homography || t.Homography == [9]float64{}
+}
+
+//Map point in rectified image to original photo coordinates
+func (t ImageTransform) mapPoint(x float64, y float64) (ox float64, oy float64) {
+	h := t.Homography
+	w := h[6]*x + h[7]*y + h[8]
+	ox = (h[0]*x + h[1]*y + h[2]) / w
+	oy = (h[3]*x + h[4]*y + h[5]) / w
+	return
+}
+
+//Map bbox in rectified image to bounding rectangle of the mapped bbox in original photo coordinates
+func (t ImageTransform) mapRectToOriginal(r image.Rectangle) (mapped image.Rectangle) {
+	if t.isIdentity() || r.Empty() {
+		return r
+	}
+
+	corners := [4][2]float64{
+		{float64(r.Min.X), float64(r.Min.Y)},
+		{float64(r.Max.X), float64(r.Min.Y)},
+		{float64(r.Max.X), float64(r.Max.Y)},
+		{float64(r.Min.X), float64(r.Max.Y)}}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range corners {
+		x, y := t.mapPoint(c[0], c[1])
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	mapped = image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+	return
+}
+
+//Multiply 3x3 row major matrices a*b
+func multiplyMatrix3(a [9]float64, b [9]float64) (m [9]float64) {
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			for k := 0; k < 3; k++ {
+				m[row*3+col] += a[row*3+k] * b[k*3+col]
+			}
+		}
+	}
+	return
+}
+
+//Rectify slide image in place. Original photo is kept with IMAGE_SUFFIX_RAW suffix.
+//Return rectified image (original image if no correction needed) and transform mapping rectified image coordinates to original photo coordinates.
+func rectifySlideImage(sReference Slide) (rectified image.Image, transform ImageTransform, err error) {
+	var original image.Image
+	if original, err = readImageFile(photoPath(sReference)); err != nil {
+		return
+	}
+
+	if rectified, transform = rectifyImage(original); transform.isIdentity() {
+		return
+	}
+
+	rawSlide := sReference
+	rawSlide.Suffix = IMAGE_SUFFIX_RAW
+	if err = copyFileContents(photoPath(sReference), photoPath(rawSlide)); err != nil {
+		return
+	}
+
+	err = writeImageFile(rectified, photoPath(sReference))
+	return
+}
+
+//Detect screen quadrilateral and text skew in src and return rectified image.
+//transform is identity and rectified is src if no correction needed.
+func rectifyImage(src image.Image) (rectified image.Image, transform ImageTransform) {
+	rectified = src
+	transform = identityImageTransform()
+
+	bounds := src.Bounds()
+	outputWidth, outputHeight := bounds.Dx(), bounds.Dy()
+
+	//Analyze downsampled grayscale image for speed
+	gray, scale := downsampleGray(src, RECTIFY_ANALYSIS_MAX_DIMENSION)
+
+	//Perspective correction from screen quadrilateral to rectangle
+	if quad, found := detectScreenQuadrilateral(gray); found {
+		for i := range quad {
+			transform.Quadrilateral[i] = image.Pt(int(float64(quad[i].X)/scale)+bounds.Min.X, int(float64(quad[i].Y)/scale)+bounds.Min.Y)
+		}
+		q := transform.Quadrilateral
+
+		outputWidth = int(math.Max(pointDistance(q[0], q[1]), pointDistance(q[3], q[2])))
+		outputHeight = int(math.Max(pointDistance(q[0], q[3]), pointDistance(q[1], q[2])))
+
+		transform.Homography = homographyFromRectangle(outputWidth, outputHeight, q)
+		gray, scale = downsampleGray(warpImage(src, outputWidth, outputHeight, transform), RECTIFY_ANALYSIS_MAX_DIMENSION)
+	}
+
+	//Deskew by rotating about image center
+	if angle := detectSkewAngle(gray); math.Abs(angle) >= RECTIFY_MIN_SKEW_DEGREES {
+		transform.SkewAngle = angle
+		radians := angle * math.Pi / 180
+		cx, cy := float64(outputWidth)/2, float64(outputHeight)/2
+		cos, sin := math.Cos(radians), math.Sin(radians)
+
+		//Rotation about center mapping deskewed coordinates to skewed coordinates
+		rotation := [9]float64{
+			cos, -sin, cx - cos*cx + sin*cy,
+			sin, cos, cy - sin*cx - cos*cy,
+			0, 0, 1}
+		transform.Homography = multiplyMatrix3(transform.Homography, rotation)
+	}
+
+	if transform.isIdentity() {
+		return
+	}
+
+	//Translate to source bounds origin
+	if bounds.Min != (image.Point{}) {
+		translation := [9]float64{1, 0, float64(bounds.Min.X), 0, 1, float64(bounds.Min.Y), 0, 0, 1}
+		transform.Homography = multiplyMatrix3(translation, transform.Homography)
+	}
+
+	rectified = warpImage(src, outputWidth, outputHeight, transform)
+	return
+}
+
+//Distance between two points
+func pointDistance(a image.Point, b image.Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+//Create width x height image by sampling src at transform mapped coordinates. Pixels mapped outside src are white.
+func warpImage(src image.Image, width int, height int, transform ImageTransform) (dst *image.RGBA) {
+	dst = image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sx, sy := transform.mapPoint(float64(x)+0.5, float64(y)+0.5)
+			if c, inside := sampleBilinear(src, sx-0.5, sy-0.5); inside {
+				dst.Set(x, y, c)
+			} else {
+				dst.Set(x, y, color.White)
+			}
+		}
+	}
+	return
+}
+
+//Sample src at fractional pixel coordinate with bilinear interpolation. inside is false if coordinate is outside src.
+func sampleBilinear(src image.Image, x float64, y float64) (c color.RGBA64, inside bool) {
+	bounds := src.Bounds()
+	if x < float64(bounds.Min.X)-0.5 || y < float64(bounds.Min.Y)-0.5 || x > float64(bounds.Max.X)-0.5 || y > float64(bounds.Max.Y)-0.5 {
+		return
+	}
+	inside = true
+
+	x0 := clampInt(int(math.Floor(x)), bounds.Min.X, bounds.Max.X-1)
+	y0 := clampInt(int(math.Floor(y)), bounds.Min.Y, bounds.Max.Y-1)
+	x1 := clampInt(x0+1, bounds.Min.X, bounds.Max.X-1)
+	y1 := clampInt(y0+1, bounds.Min.Y, bounds.Max.Y-1)
+	fx := math.Max(0, math.Min(1, x-float64(x0)))
+	fy := math.Max(0, math.Min(1, y-float64(y0)))
+
+	var channels [3]float64
+	for _, corner := range []struct {
+		X, Y   int
+		Weight float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x1, y0, fx * (1 - fy)},
+		{x0, y1, (1 - fx) * fy},
+		{x1, y1, fx * fy}} {
+		r, g, b, _ := src.At(corner.X, corner.Y).RGBA()
+		channels[0] += float64(r) * corner.Weight
+		channels[1] += float64(g) * corner.Weight
+		channels[2] += float64(b) * corner.Weight
+	}
+
+	c = color.RGBA64{
+		R: uint16(channels[0]),
+		G: uint16(channels[1]),
+		B: uint16(channels[2]),
+		A: 0xffff}
+	return
+}
+
+//Return grayscale copy of src no larger than maxDimension and the scale applied.
+func downsampleGray(src image.Image, maxDimension int) (gray *image.Gray, scale float64) {
+	bounds := src.Bounds()
+	scale = 1
+	if largest := math.Max(float64(bounds.Dx()), float64(bounds.Dy())); largest > float64(maxDimension) {
+		scale = float64(maxDimension) / largest
+	}
+
+	width := int(float64(bounds.Dx()) * scale)
+	height := int(float64(bounds.Dy()) * scale)
+	gray = image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			gray.Set(x, y, src.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale)))
+		}
+	}
+	return
+}
+
+//Return Otsu threshold separating gray pixels into two classes
+func otsuThreshold(gray *image.Gray) (threshold uint8) {
+	var histogram [256]int
+	for _, p := range gray.Pix {
+		histogram[p]++
+	}
+
+	total := len(gray.Pix)
+	var sumAll float64
+	for i, count := range histogram {
+		sumAll += float64(i * count)
+	}
+
+	var sumBackground float64
+	var weightBackground int
+	var bestVariance float64
+	for i, count := range histogram {
+		weightBackground += count
+		if weightBackground == 0 {
+			continue
+		}
+		weightForeground := total - weightBackground
+		if weightForeground == 0 {
+			break
+		}
+
+		sumBackground += float64(i * count)
+		meanBackground := sumBackground / float64(weightBackground)
+		meanForeground := (sumAll - sumBackground) / float64(weightForeground)
+
+		variance := float64(weightBackground) * float64(weightForeground) * (meanBackground - meanForeground) * (meanBackground - meanForeground)
+		if variance > bestVariance {
+			bestVariance = variance
+			threshold = uint8(i)
+		}
+	}
+	return
+}
+
+//Find corners of the largest bright region (lit screen or board) in gray.
+//Corners are ordered top left, top right, bottom right, bottom left.
+//found is false if region is too small or is already an axis aligned rectangle.
+func detectScreenQuadrilateral(gray *image.Gray) (quad [4]image.Point, found bool) {
+	width, height := gray.Bounds().Dx(), gray.Bounds().Dy()
+	if width == 0 || height == 0 {
+		return
+	}
+	threshold := otsuThreshold(gray)
+
+	//Label connected bright regions and keep the largest
+	visited := make([]bool, width*height)
+	var largest []int
+	for start := range gray.Pix {
+		if visited[start] || gray.Pix[start] <= threshold {
+			continue
+		}
+
+		region := []int{start}
+		visited[start] = true
+		for i := 0; i < len(region); i++ {
+			x, y := region[i]%width, region[i]/width
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[1] < 0 || n[0] >= width || n[1] >= height {
+					continue
+				}
+				index := n[1]*width + n[0]
+				if !visited[index] && gray.Pix[index] > threshold {
+					visited[index] = true
+					region = append(region, index)
+				}
+			}
+		}
+
+		if len(region) > len(largest) {
+			largest = region
+		}
+	}
+
+	if len(largest) == 0 {
+		return
+	}
+
+	//Corners are the extreme points of x+y and x-y
+	minSum, maxSum := math.MaxInt32, math.MinInt32
+	minDiff, maxDiff := math.MaxInt32, math.MinInt32
+	for _, index := range largest {
+		x, y := index%width, index/width
+		if x+y < minSum {
+			minSum = x + y
+			quad[0] = image.Pt(x, y)
+		}
+		if x+y > maxSum {
+			maxSum = x + y
+			quad[2] = image.Pt(x, y)
+		}
+		if x-y > maxDiff {
+			maxDiff = x - y
+			quad[1] = image.Pt(x, y)
+		}
+		if x-y < minDiff {
+			minDiff = x - y
+			quad[3] = image.Pt(x, y)
+		}
+	}
+
+	//Ignore small regions
+	area := quadrilateralArea(quad)
+	if area < RECTIFY_MIN_SCREEN_AREA*float64(width*height) {
+		return
+	}
+
+	//Ignore regions that are already axis aligned rectangles such as screenshots and table areas of screenshots
+	tolerance := RECTIFY_CORNER_TOLERANCE * math.Max(float64(width), float64(height))
+	if math.Abs(float64(quad[0].Y-quad[1].Y)) <= tolerance && math.Abs(float64(quad[3].Y-quad[2].Y)) <= tolerance &&
+		math.Abs(float64(quad[0].X-quad[3].X)) <= tolerance && math.Abs(float64(quad[1].X-quad[2].X)) <= tolerance {
+		return
+	}
+
+	found = true
+	return
+}
+
+//Area of quadrilateral with shoelace formula
+func quadrilateralArea(quad [4]image.Point) float64 {
+	var sum int
+	for i := range quad {
+		next := quad[(i+1)%len(quad)]
+		sum += quad[i].X*next.Y - next.X*quad[i].Y
+	}
+	return math.Abs(float64(sum)) / 2
+}
+
+//Return homography mapping width x height rectangle corners to quad corners (top left, top right, bottom right, bottom left).
+func homographyFromRectangle(width int, height int, quad [4]image.Point) (h [9]float64) {
+	rect := [4][2]float64{{0, 0}, {float64(width), 0}, {float64(width), float64(height)}, {0, float64(height)}}
+
+	//Solve 8x8 linear system A*x = b for h0..h7 with h8 = 1
+	var a [8][9]float64
+	for i := 0; i < 4; i++ {
+		x, y := rect[i][0], rect[i][1]
+		u, v := float64(quad[i].X), float64(quad[i].Y)
+		a[i*2] = [9]float64{x, y, 1, 0, 0, 0, -x * u, -y * u, u}
+		a[i*2+1] = [9]float64{0, 0, 0, x, y, 1, -x * v, -y * v, v}
+	}
+
+	//Gaussian elimination with partial pivoting
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		if a[col][col] == 0 {
+			return identityImageTransform().Homography
+		}
+
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+		}
+	}
+
+	for i := 0; i < 8; i++ {
+		h[i] = a[i][8] / a[i][i]
+	}
+	h[8] = 1
+	return
+}
+
+//Find dominant text line angle in degrees with horizontal projection profiles.
+//Text pixels are the minority Otsu class so both dark on light and light on dark slides work.
+func detectSkewAngle(gray *image.Gray) (angle float64) {
+	threshold := otsuThreshold(gray)
+
+	var dark, light []image.Point
+	width := gray.Bounds().Dx()
+	for i, p := range gray.Pix {
+		point := image.Pt(i%width, i/width)
+		if p <= threshold {
+			dark = append(dark, point)
+		} else {
+			light = append(light, point)
+		}
+	}
+	text := dark
+	if len(light) < len(dark) {
+		text = light
+	}
+	if len(text) == 0 {
+		return
+	}
+
+	//Score each candidate angle by sum of squared row counts. Rows align with text lines at the correct angle.
+	bestScore := -1.0
+	for candidate := -RECTIFY_MAX_SKEW_DEGREES; candidate <= RECTIFY_MAX_SKEW_DEGREES; candidate += RECTIFY_SKEW_STEP_DEGREES {
+		radians := candidate * math.Pi / 180
+		sin, cos := math.Sin(radians), math.Cos(radians)
+
+		rows := make(map[int]int)
+		for _, p := range text {
+			rows[int(math.Floor(-float64(p.X)*sin+float64(p.Y)*cos))]++
+		}
+
+		var score float64
+		for _, count := range rows {
+			score += float64(count * count)
+		}
+
+		//Prefer smallest rotation on ties
+		if score > bestScore || (score == bestScore && math.Abs(candidate) < math.Abs(angle)) {
+			bestScore = score
+			angle = candidate
+		}
+	}
+	return
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+//Image filter creating a variant of the original slide image
+type ImageVariantFilter func(src image.Image) image.Image
+
+//Preprocessing variant of a slide image fed to OCR.
+type ImageVariant struct {
+	Name      string        //Name used in terminal file "variants" field
+	SaveType  SaveImageType //Slide.SaveType of variant slides
+	Directory string        //Directory variant images are saved in
+	Scale     int           //Variant image size relative to original image. OCR word boxes are scaled back to original image coordinates.
+	Filter    ImageVariantFilter
+}
+
+//Variants used for terminals without "variants" field
+var defaultImageVariantNames = []string{IMAGE_VARIANT_ORIGINAL, IMAGE_VARIANT_BLACK, IMAGE_VARIANT_WHITE}
+
+//Registered ImageVariants indexed by SaveType.
+var imageVariants = []ImageVariant{
+	ImageVariant{
+		Name:      IMAGE_VARIANT_ORIGINAL,
+		SaveType:  SAVE_IMAGE_TRAINING,
+		Directory: IMAGE_TRAINING_DIRECTORY,
+		Scale:     1},
+	ImageVariant{
+		Name:      IMAGE_VARIANT_BLACK,
+		SaveType:  SAVE_IMAGE_TRAINING_PROCESSED_BLACK,
+		Directory: IMAGE_TRAINING_PROCESSED_DIRECTORY_BLACK,
+		Scale:     1,
+		Filter: func(src image.Image) image.Image {
+			return isolateBlackText(src, IMAGE_PROCESSING_BLACK_FUZZ)
+		}},
+	ImageVariant{
+		Name:      IMAGE_VARIANT_WHITE,
+		SaveType:  SAVE_IMAGE_TRAINING_PROCESSED_WHITE,
+		Directory: IMAGE_TRAINING_PROCESSED_DIRECTORY_WHITE,
+		Scale:     1,
+		Filter: func(src image.Image) image.Image {
+			return isolateWhiteText(src, IMAGE_PROCESSING_WHITE_FUZZ)
+		}},
+}
+
+//Register the built in variants beyond the original/black/white variants.
+func init() {
+	registerImageVariant(IMAGE_VARIANT_GRAYSCALE, 1, func(src image.Image) image.Image {
+		return grayscaleImage(src)
+	})
+	registerImageVariant(IMAGE_VARIANT_THRESHOLD, 1, func(src image.Image) image.Image {
+		return adaptiveThresholdImage(grayscaleImage(src), IMAGE_VARIANT_THRESHOLD_WINDOW, IMAGE_VARIANT_THRESHOLD_OFFSET)
+	})
+	registerImageVariant(IMAGE_VARIANT_INVERT, 1, func(src image.Image) image.Image {
+		return invertImage(src)
+	})
+	registerImageVariant(IMAGE_VARIANT_UPSCALE, IMAGE_VARIANT_UPSCALE_FACTOR, func(src image.Image) image.Image {
+		return upscaleImage(src, IMAGE_VARIANT_UPSCALE_FACTOR)
+	})
+	registerImageVariant(IMAGE_VARIANT_RED, 1, func(src image.Image) image.Image {
+		return channelImage(src, 0)
+	})
+	registerImageVariant(IMAGE_VARIANT_GREEN, 1, func(src image.Image) image.Image {
+		return channelImage(src, 1)
+	})
+	registerImageVariant(IMAGE_VARIANT_BLUE, 1, func(src image.Image) image.Image {
+		return channelImage(src, 2)
+	})
+}
+
+//Register an ImageVariant under name and return its newly assigned SaveImageType.
+//Variant images are saved in directory IMAGE_VARIANT_DIRECTORY_PREFIX + name.
+func registerImageVariant(name string, scale int, filter ImageVariantFilter) (saveType SaveImageType) {
+	saveType = SaveImageType(len(imageVariants))
+	imageVariants = append(imageVariants, ImageVariant{
+		Name:      name,
+		SaveType:  saveType,
+		Directory: IMAGE_VARIANT_DIRECTORY_PREFIX + name,
+		Scale:     scale,
+		Filter:    filter})
+	return
+}
+
+//Return ImageVariant for SaveImageType.
+func imageVariantForSaveType(saveType SaveImageType) (variant ImageVariant, err error) {
+	if saveType < 0 || int(saveType) >= len(imageVariants) {
+		err = fmt.Errorf("Unknown save type %v", saveType)
+		return
+	}
+	variant = imageVariants[saveType]
+	return
+}
+
+//Return ImageVariant registered under name.
+func imageVariantForName(name string) (variant ImageVariant, err error) {
+	for _, v := range imageVariants {
+		if v.Name == name {
+			variant = v
+			return
+		}
+	}
+	err = fmt.Errorf("Unknown image variant %v", name)
+	return
+}
+
+//Return ImageVariants selected by Terminal "variants" field in OCR order. Terminals without variants use defaultImageVariantNames.
+func imageVariantsForTerminal(t Terminal) (variants []ImageVariant, err error) {
+	names := t.VariantNames
+	if len(names) == 0 {
+		names = defaultImageVariantNames
+	}
+
+	for _, name := range names {
+		var variant ImageVariant
+		if variant, err = imageVariantForName(name); err != nil {
+			err = fmt.Errorf("%v for terminal %v.", err, t.Title)
+			return
+		}
+		variants = append(variants, variant)
+	}
+	return
+}
+
+//Return directories of all registered ImageVariants.
+func imageVariantDirectories() (directories []string) {
+	for _, v := range imageVariants {
+		directories = append(directories, v.Directory)
+	}
+	return
+}
+
+//Return size of slide image relative to original image.
+func (sReference Slide) imageScale() (scale int) {
+	scale = 1
+	if variant, err := imageVariantForSaveType(sReference.SaveType); err == nil && variant.Scale > 1 {
+		scale = variant.Scale
+	}
+	return
+}
+
+//Multiply rectangle coordinates by scale.
+func scaleRect(r image.Rectangle, scale int) image.Rectangle {
+	return image.Rect(r.Min.X*scale, r.Min.Y*scale, r.Max.X*scale, r.Max.Y*scale)
+}
+
+/*
+ * Variant filters
+ */
+
+//Convert image to 8 bit grayscale.
+func grayscaleImage(src image.Image) (dst *image.Gray) {
+	bounds := src.Bounds()
+	dst = image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			dst.Set(x-bounds.Min.X, y-bounds.Min.Y, src.At(x, y))
+		}
+	}
+	return
+}
+
+//Set pixels darker than the mean of the surrounding window by more than offset to black and all other pixels to white.
+//Handles slides with uneven lighting and colored rows where a single global threshold fails.
+func adaptiveThresholdImage(src *image.Gray, window int, offset int) (dst *image.Gray) {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dst = image.NewGray(image.Rect(0, 0, width, height))
+
+	//Integral image of pixel sums. integral[(y)*(width+1)+x] is sum of pixels above and left of x,y.
+	integral := make([]int, (width+1)*(height+1))
+	for y := 0; y < height; y++ {
+		rowSum := 0
+		for x := 0; x < width; x++ {
+			rowSum += int(src.Pix[y*src.Stride+x])
+			integral[(y+1)*(width+1)+x+1] = integral[y*(width+1)+x+1] + rowSum
+		}
+	}
+
+	half := window / 2
+	for y := 0; y < height; y++ {
+		minY, maxY := y-half, y+half+1
+		if minY < 0 {
+			minY = 0
+		}
+		if maxY > height {
+			maxY = height
+		}
+		for x := 0; x < width; x++ {
+			minX, maxX := x-half, x+half+1
+			if minX < 0 {
+				minX = 0
+			}
+			if maxX > width {
+				maxX = width
+			}
+
+			sum := integral[maxY*(width+1)+maxX] - integral[minY*(width+1)+maxX] - integral[maxY*(width+1)+minX] + integral[minY*(width+1)+minX]
+			mean := sum / ((maxX - minX) * (maxY - minY))
+
+			if int(src.Pix[y*src.Stride+x]) < mean-offset {
+				dst.Pix[y*dst.Stride+x] = 0
+			} else {
+				dst.Pix[y*dst.Stride+x] = 0xff
+			}
+		}
+	}
+	return
+}
+
+//Negate image colors.
+func invertImage(src image.Image) (dst *image.RGBA) {
+	bounds := src.Bounds()
+	dst = image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := src.At(x, y).RGBA()
+			dst.Set(x-bounds.Min.X, y-bounds.Min.Y, color.RGBA64{
+				R: uint16(0xffff - r),
+				G: uint16(0xffff - g),
+				B: uint16(0xffff - b),
+				A: 0xffff})
+		}
+	}
+	return
+}
+
+//Enlarge image by integer factor with bilinear interpolation. Small schedule text OCRs better when enlarged.
+func upscaleImage(src image.Image, factor int) (dst *image.RGBA) {
+	bounds := src.Bounds()
+	dst = image.NewRGBA(image.Rect(0, 0, bounds.Dx()*factor, bounds.Dy()*factor))
+
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			//Source coordinate of destination pixel center
+			sx := (float64(x)+0.5)/float64(factor) - 0.5
+			sy := (float64(y)+0.5)/float64(factor) - 0.5
+			c, _ := sampleBilinear(src, float64(bounds.Min.X)+sx, float64(bounds.Min.Y)+sy)
+			dst.Set(x, y, c)
+		}
+	}
+	return
+}
+
+//Return grayscale image of a single color channel. channel is 0 red, 1 green, 2 blue.
+//Text printed on rows of one color stands out in the channel of the opposite color.
+func channelImage(src image.Image, channel int) (dst *image.Gray) {
+	bounds := src.Bounds()
+	dst = image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := src.At(x, y).RGBA()
+			value := [3]uint32{r, g, b}[channel]
+			dst.Pix[(y-bounds.Min.Y)*dst.Stride+(x-bounds.Min.X)] = uint8(value >> 8)
+		}
+	}
+	return
+}
+
+//Clamp value to min and max inclusive.
+func clampInt(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
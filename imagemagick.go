@@ -3,11 +3,6 @@ package main
 import (
 	"errors"
 	"fmt"
-	"image"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
-	"os"
 	"os/exec"
 	//"log"
 )
@@ -87,43 +82,6 @@ func runImageMagickColorProcess(sourceSaveType SaveImageType, sReference Slide)
 	return
 }
 
-//Run image crop on a slide from sReference.saveType location to some directory with suffix on output filename.
-//Crop vertically to form horizonal row image
-func runImageMagickDateCropVerticalProcess(sReference Slide, cropVerticalOffset int, cropHeight int) (err error) {
-
-	//find original dimensions
-	originalSavePath := photoPath(sReference)
-	var reader *os.File
-	if reader, err = os.Open(originalSavePath); err != nil {
-		return
-	}
-
-	//load image
-	defer reader.Close()
-	var im image.Config
-	if im, _, err = image.DecodeConfig(reader); err != nil {
-		return
-	}
-
-	err = runImageMagickCropProcess(sReference, []int{im.Width, cropHeight, 0, cropVerticalOffset})
-
-	return
-}
-
-//Run image crop on a slide from sReference.saveType location to some directory with suffix on output filename.
-//Crop horizontal starting top at cropVerticalOffset to form vertical column image
-func runImageMagickDateCropHorizontalProcess(sReference Slide, cropHorizontalMinMax image.Point, cropVerticalOffset int) (err error) {
-
-	var im image.Config
-	if im, err = sReference.getImageConfig(); err != nil {
-		return
-	}
-
-	err = runImageMagickCropProcess(sReference, []int{cropHorizontalMinMax.Y - cropHorizontalMinMax.X, im.Height - cropVerticalOffset, cropHorizontalMinMax.X, cropVerticalOffset})
-
-	return
-}
-
 //Run image crop with bbox with geometry params
 func runImageMagickCropProcess(sReference Slide, cropGeometry []int) (err error) {
 	if len(cropGeometry) != 4 {
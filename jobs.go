@@ -0,0 +1,216 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+//Photo jobs created by this process keyed by job id
+var photoJobs = make(map[string]*PhotoJob)
+var photoJobsLock sync.Mutex
+
+//Returned by newUploadPhotoJob if PHOTO_JOB_QUEUE_LENGTH uploaded photos are already waiting
+var errPhotoJobQueueFull = fmt.Errorf("Photo job queue full (%v jobs).", PHOTO_JOB_QUEUE_LENGTH)
+
+//Create a new queued PhotoJob run by this process for photo from Terminal and store it.
+func newPhotoJob(t Terminal, photo SourcePhoto) (job *PhotoJob, err error) {
+	var id string
+	if id, err = newPhotoJobId(); err != nil {
+		return
+	}
+
+	job = &PhotoJob{
+		Id:            id,
+		State:         JOB_STATE_QUEUED,
+		Terminal:      t,
+		TerminalTitle: t.Title,
+		Photo:         photo,
+		PhotoSource:   photo.Id,
+		SubmitDate:    time.Now(),
+		UpdateDate:    time.Now(),
+		Worker:        *processMode}
+
+	photoJobsLock.Lock()
+	//Remove old jobs to limit memory
+	for oldId, oldJob := range photoJobs {
+		if time.Since(oldJob.SubmitDate) > PHOTO_JOB_MAX_AGE {
+			delete(photoJobs, oldId)
+		}
+	}
+	photoJobs[id] = job
+	photoJobsLock.Unlock()
+
+	job.save()
+	return
+}
+
+//Return random hex job id
+func newPhotoJobId() (id string, err error) {
+	idBytes := make([]byte, PHOTO_JOB_ID_BYTES)
+	if _, err = rand.Read(idBytes); err != nil {
+		return
+	}
+	id = hex.EncodeToString(idBytes)
+	return
+}
+
+//Find job by id in this process or in photo jobs table.
+func findPhotoJob(id string) (job PhotoJob, found bool, err error) {
+	photoJobsLock.Lock()
+	if jobP, ok := photoJobs[id]; ok {
+		job = *jobP
+		found = true
+	}
+	photoJobsLock.Unlock()
+
+	if found {
+		return
+	}
+
+	job, found, err = selectPhotoJobFromTable(PHOTO_JOBS_TABLE, id)
+	return
+}
+
+//Queue uploaded photo from Terminal in photo jobs table for a worker process. Image data is stored with the job.
+//Returns errPhotoJobQueueFull if PHOTO_JOB_QUEUE_LENGTH uploaded photos are already queued.
+func newUploadPhotoJob(t Terminal, photo SourcePhoto, imageData []byte) (job PhotoJob, err error) {
+	var queued int
+	if queued, err = selectQueuedPhotoJobCountFromTable(PHOTO_JOBS_TABLE, PHOTO_UPLOAD_ID_PREFIX); err != nil {
+		return
+	}
+	if queued >= PHOTO_JOB_QUEUE_LENGTH {
+		err = errPhotoJobQueueFull
+		return
+	}
+
+	var id string
+	if id, err = newPhotoJobId(); err != nil {
+		return
+	}
+
+	job = PhotoJob{
+		Id:            id,
+		State:         JOB_STATE_QUEUED,
+		Terminal:      t,
+		TerminalTitle: t.Title,
+		Photo:         photo,
+		PhotoSource:   photo.Id,
+		SubmitDate:    time.Now(),
+		UpdateDate:    time.Now()}
+
+	err = insertUploadPhotoJobIntoTable(PHOTO_JOBS_TABLE, job, imageData)
+	return
+}
+
+//Set job state and error and save job. No-op for nil job.
+func (job *PhotoJob) setState(state string, jobErr error) {
+	if job == nil {
+		return
+	}
+
+	photoJobsLock.Lock()
+	job.State = state
+	if jobErr != nil {
+		job.Error = jobErr.Error()
+	}
+	photoJobsLock.Unlock()
+
+	job.save()
+}
+
+//Set detected slide date of job. Saved on next state change. No-op for nil job.
+func (job *PhotoJob) setSlideDate(slideDate time.Time) {
+	if job == nil {
+		return
+	}
+
+	photoJobsLock.Lock()
+	job.SlideDate = slideDate
+	photoJobsLock.Unlock()
+}
+
+//Set flights produced by job. Saved on next state change. No-op for nil job.
+func (job *PhotoJob) setFlights(flights []Flight) {
+	if job == nil {
+		return
+	}
+
+	photoJobsLock.Lock()
+	job.Flights = flights
+	photoJobsLock.Unlock()
+}
+
+//Save copy of job to photo jobs table so other processes can report job status.
+//Errors are logged because job status is informational and should not stop processing.
+func (job *PhotoJob) save() {
+	photoJobsLock.Lock()
+	job.UpdateDate = time.Now()
+	jobCopy := *job
+	photoJobsLock.Unlock()
+
+	if err := insertPhotoJobIntoTable(PHOTO_JOBS_TABLE, jobCopy); err != nil {
+		log.Printf("Save photo job %v error: %v\n", jobCopy.Id, err)
+	}
+}
+
+//Process uploaded photo jobs queued in photo jobs table one at a time. Does not return.
+func runPhotoJobWorker(terminalMap map[string]Terminal) {
+	for {
+		job, found, err := claimQueuedPhotoJobFromTable(PHOTO_JOBS_TABLE, PHOTO_UPLOAD_ID_PREFIX, *processMode)
+		if err != nil {
+			log.Println("Claim photo job error: ", err)
+		}
+		if !found {
+			time.Sleep(PHOTO_JOB_POLL_INTERVAL)
+			continue
+		}
+
+		processPhotoJob(&job, terminalMap)
+	}
+}
+
+//OCR and store flights for uploaded photo of PhotoJob.
+func processPhotoJob(job *PhotoJob, terminalMap map[string]Terminal) {
+	var err error
+
+	var ok bool
+	if job.Terminal, ok = terminalMap[job.TerminalTitle]; !ok {
+		job.setState(JOB_STATE_FAILED, fmt.Errorf("Unknown terminal %v.", job.TerminalTitle))
+		return
+	}
+	job.Photo = SourcePhoto{
+		Id:          job.PhotoSource,
+		CreatedTime: job.SubmitDate}
+
+	//Create fuzzy models for lookup if no other user holds them
+	if err = acquireFuzzyModels(); err != nil {
+		job.setState(JOB_STATE_FAILED, err)
+		return
+	}
+	defer releaseFuzzyModels()
+
+	var flightsFound int
+	if flightsFound, err = processPhotoNode(job.Photo, uploadPhotoSource{}, job.Terminal, job); err != nil {
+		displayErrorForTerminal(job.Terminal, err.Error())
+		return
+	}
+
+	log.Printf("Photo job %v stored %v flights.\n", job.Id, flightsFound)
+}
+
+//PhotoSource for photos uploaded through POST /photos. Photos are queued by the upload handler so RecentPhotos lists nothing.
+type uploadPhotoSource struct{}
+
+func (uploadPhotoSource) RecentPhotos(t Terminal) (photos []SourcePhoto, err error) {
+	return
+}
+
+//Read uploaded image stored with its job in photo jobs table.
+func (uploadPhotoSource) PhotoImage(photo SourcePhoto) (imageData []byte, err error) {
+	imageData, err = selectPhotoJobImageFromTable(PHOTO_JOBS_TABLE, photo.Id)
+	return
+}
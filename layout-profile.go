@@ -0,0 +1,150 @@
+package main
+
+import (
+	"image"
+	"sync"
+	"time"
+)
+
+/*
+ * Learned slide layout profiles
+ * Label positions of confidently parsed slides are averaged per terminal and used when a label is missing or misread in a new photo.
+ */
+
+//Serialize profile read-update-write so concurrently processed photos of a terminal do not drop samples
+var layoutProfileMutex sync.Mutex
+
+//Convert pixel rectangle to region as fractions of image size
+func layoutRegionForRect(r image.Rectangle, width int, height int) LayoutRegion {
+	return LayoutRegion{
+		Left:   float64(r.Min.X) / float64(width),
+		Top:    float64(r.Min.Y) / float64(height),
+		Right:  float64(r.Max.X) / float64(width),
+		Bottom: float64(r.Max.Y) / float64(height)}
+}
+
+//Add label bbox found in slide image to learned average. Previous samples are weighted at most LAYOUT_PROFILE_MAX_SAMPLES.
+func (p *LayoutProfile) learnLabel(label string, bbox image.Rectangle, im image.Config) {
+	if p.Labels == nil {
+		p.Labels = make(map[string]LearnedLabel)
+	}
+
+	region := layoutRegionForRect(bbox, im.Width, im.Height)
+	learned, ok := p.Labels[label]
+	if !ok {
+		p.Labels[label] = LearnedLabel{Region: region, Samples: 1}
+		return
+	}
+
+	weight := float64(learned.Samples)
+	if learned.Samples > LAYOUT_PROFILE_MAX_SAMPLES {
+		weight = float64(LAYOUT_PROFILE_MAX_SAMPLES)
+	}
+	average := func(previous float64, sample float64) float64 {
+		return (previous*weight + sample) / (weight + 1)
+	}
+
+	learned.Region = LayoutRegion{
+		Left:   average(learned.Region.Left, region.Left),
+		Top:    average(learned.Region.Top, region.Top),
+		Right:  average(learned.Region.Right, region.Right),
+		Bottom: average(learned.Region.Bottom, region.Bottom)}
+	learned.Samples++
+	p.Labels[label] = learned
+}
+
+//Return learned label bbox for image size. ok is false if label not learned from LAYOUT_PROFILE_MIN_SAMPLES slides.
+func (p LayoutProfile) labelRect(label string, width int, height int) (bbox image.Rectangle, ok bool) {
+	learned, found := p.Labels[label]
+	if !found || learned.Samples < LAYOUT_PROFILE_MIN_SAMPLES {
+		return
+	}
+	return learned.Region.rect(width, height), true
+}
+
+//Read learned profile of terminal. Profiles are not stored in DEBUG_MANUAL_IMAGE_FILE_TARGET mode.
+func layoutProfileForTerminal(t Terminal) (profile LayoutProfile, found bool, err error) {
+	if DEBUG_MANUAL_IMAGE_FILE_TARGET {
+		return
+	}
+	return selectLayoutProfileFromTable(LAYOUT_PROFILES_TABLE, t.Title)
+}
+
+//Return learned label bbox of terminal profile for slides. ok is false if terminal has no usable learned label.
+func findLearnedLabelBoundsOfPhotoNodeSlides(slides []Slide, label string) (bbox image.Rectangle, ok bool, err error) {
+	var profile LayoutProfile
+	var found bool
+	if profile, found, err = layoutProfileForTerminal(slides[0].Terminal); err != nil || !found {
+		return
+	}
+
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+
+	bbox, ok = profile.labelRect(label, im.Width, im.Height)
+	return
+}
+
+//Find label bbox in slides. If label is not found, use learned label bbox of terminal profile instead.
+//learned is true if bbox is from profile. Label lookup error is returned if terminal has no usable learned label.
+func findLabelBoundsOrLearnedOfPhotoNodeSlides(slides []Slide, label string) (bbox image.Rectangle, learned bool, err error) {
+	if bbox, err = findLabelBoundsOfPhotoNodeSlides(slides, label); err == nil {
+		return
+	}
+
+	labelErr := err
+	var ok bool
+	if bbox, ok, err = findLearnedLabelBoundsOfPhotoNodeSlides(slides, label); err != nil {
+		return
+	}
+	if !ok {
+		err = labelErr
+		return
+	}
+
+	displayMessageForTerminal(slides[0].Terminal, "Using learned "+label+" label position.")
+	learned = true
+	return
+}
+
+//Add label bboxes found by OCR in confidently parsed slides to terminal profile.
+//labels maps KEYWORD_XXX label to bbox. Parse is confident if at least LAYOUT_PROFILE_MIN_FLIGHTS flights have roll call times.
+func learnLayoutProfileFromSlides(slides []Slide, labels map[string]image.Rectangle, flights []Flight) (err error) {
+	if DEBUG_MANUAL_IMAGE_FILE_TARGET || len(labels) == 0 {
+		return
+	}
+
+	var flightsWithRollCall int
+	for _, f := range flights {
+		if !f.RollCall.IsZero() {
+			flightsWithRollCall++
+		}
+	}
+	if flightsWithRollCall < LAYOUT_PROFILE_MIN_FLIGHTS {
+		return
+	}
+
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+
+	layoutProfileMutex.Lock()
+	defer layoutProfileMutex.Unlock()
+
+	var profile LayoutProfile
+	if profile, _, err = layoutProfileForTerminal(slides[0].Terminal); err != nil {
+		return
+	}
+	profile.TerminalTitle = slides[0].Terminal.Title
+
+	for label, bbox := range labels {
+		profile.learnLabel(label, bbox, im)
+	}
+	profile.UpdateDate = time.Now()
+
+	err = upsertLayoutProfileIntoTable(LAYOUT_PROFILES_TABLE, profile)
+	return
+}
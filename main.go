@@ -15,7 +15,13 @@ import (
  */
 //import _ "net/http/pprof"
 
-var processMode = flag.String("procMode", "all", "Process Mode for server. all/web/worker")
+var processMode = flag.String("procMode", "all", "Process Mode for server. all/web/worker/dropfolder")
+var dropFolderDirectory = flag.String("dropFolder", DROP_FOLDER_DIRECTORY, "Drop folder root directory laid out as <terminal id>/<image files>")
+var ocrEngineName = flag.String("ocrEngine", OCR_ENGINE_TESSERACT, "OCR engine. tesseract/replay, or gosseract when built with -tags gosseract")
+var ocrFixtureDirectory = flag.String("ocrFixtures", OCR_FIXTURE_DIRECTORY, "Directory of recorded OCR fixtures read by replay OCR engine and written by -ocrRecord")
+var ocrRecord = flag.Bool("ocrRecord", false, "Record OCR engine results as fixtures in -ocrFixtures directory")
+var imageProcessorName = flag.String("imageProcessor", IMAGE_PROCESSOR_IMAGEMAGICK, "Image processor for color variants and crops. imagemagick/go")
+var rectifyPhotos = flag.Bool("rectify", false, "Correct perspective and skew of photos of screens before OCR for all terminals. Terminals can also opt in with \"rectify\" in the terminal file")
 
 func main() {
 	//fmt.Printf("\n\u001b[1mboldtext\u001b[0m\r\u001b[2Fprevline\n\n\n")
@@ -39,7 +45,7 @@ func main() {
 	startWorkerMode := func() {
 		var err error
 
-		if err = createImageDirectories(IMAGE_TMP_DIRECTORY, IMAGE_TRAINING_DIRECTORY, IMAGE_TRAINING_PROCESSED_DIRECTORY_BLACK, IMAGE_TRAINING_PROCESSED_DIRECTORY_WHITE); err != nil {
+		if err = createImageDirectories(append([]string{IMAGE_TMP_DIRECTORY}, imageVariantDirectories()...)...); err != nil {
 			log.Println(err)
 		}
 
@@ -74,8 +80,8 @@ func main() {
 	    log.Println("DEBUG_MANUAL_IMAGE_FILE_TARGET on " + photoPath(Slide{
 	    	SaveType:SAVE_IMAGE_TRAINING}))
 
-			processPhotoNode(PhotosEdgePhoto{
-				UpdatedTime: time.Now().Format("2006-01-02T15:04:05-0700")}, debugTerminal)
+			processPhotoNode(SourcePhoto{
+				CreatedTime: time.Now()}, nil, debugTerminal, nil)
 			log.Fatal("DEBUG_MANUAL_IMAGE_FILE_TARGET complete")
 		}
 		
@@ -114,10 +120,31 @@ func main() {
 				log.Println(err)
 				return
 			}
+
+			//Jobs left unfinished by a previous run of this procMode never finish
+			if err = updateUnfinishedPhotoJobsOfWorkerToFailedInTable(PHOTO_JOBS_TABLE, *processMode); err != nil {
+				log.Println("Fail unfinished photo jobs error: ", err)
+			}
+
+			//Process uploaded photos queued by web process
+			if *processMode != "dropfolder" {
+				go runPhotoJobWorker(terminalMap)
+			}
 		}
 
 		log.Printf("\u001b[1m\u001b[35m%v\u001b[0m\n", "Starting Update")
 
+		//Watch drop folder instead of periodic update
+		if *processMode == "dropfolder" {
+			//Create fuzzy models for lookup. Kept for lifetime of watcher.
+			if err = acquireFuzzyModels(); err != nil {
+				log.Fatal(err)
+			}
+
+			watchDropFolder(dropFolderPhotoSource{Directory: *dropFolderDirectory}, terminalArray)
+			return
+		}
+
 		//Update terminal flights every hour
 		updateAllTerminalsFlights(terminalMap)
 		for _ = range time.Tick(time.Minute * 30) {
@@ -127,18 +154,43 @@ func main() {
 			if err = deleteFlightsFromTableBetweenTimesForOrigin(FLIGHTS_72HR_TABLE, time.Now(), current.Add(-time.Hour * 24 * time.Duration(FLIGHTS_MAX_SOURCEDATE_AGE_DAYS)),""); err != nil {
 				log.Println("Purge old flights error: ", err)
 			}
+			if err = deletePhotoJobsFromTableBeforeTime(PHOTO_JOBS_TABLE, current.Add(-PHOTO_JOB_MAX_AGE)); err != nil {
+				log.Println("Purge old photo jobs error: ", err)
+			}
 		}
 		//go updateAllTerminalsFlights(terminalMap)
 	}
 
 	//Parse cmd parameters and launch appropriate mode
 	flag.Parse()
+
+	//Select OCR engine
+	var err error
+	if ocrEngine, err = newOCREngine(*ocrEngineName, *ocrFixtureDirectory, *ocrRecord); err != nil {
+		log.Println(err)
+		flag.PrintDefaults()
+		return
+	}
+
+	//Select image processor
+	if imageProcessor, err = imageProcessorForName(*imageProcessorName); err != nil {
+		log.Println(err)
+		flag.PrintDefaults()
+		return
+	}
+
+	//Drop folder PhotoSource for terminals with "photoSource": "dropfolder"
+	registerPhotoSource(PHOTO_SOURCE_DROP_FOLDER, dropFolderPhotoSource{Directory: *dropFolderDirectory})
+
 	if *processMode == "web" {
 		connectDatabase()
 		startWebMode()
 	} else if *processMode == "worker" {
 		connectDatabase()
 		startWorkerMode()
+	} else if *processMode == "dropfolder" {
+		connectDatabase()
+		startWorkerMode()
 	} else if *processMode == "all" {
 		connectDatabase()
 		startWebMode()
@@ -0,0 +1,63 @@
+//go:build gosseract
+// +build gosseract
+
+package main
+
+import (
+	"github.com/otiai10/gosseract"
+	"image"
+	"path/filepath"
+)
+
+/*
+ * In-process gosseract OCREngine
+ * Build with -tags gosseract. Requires libtesseract and leptonica headers. gosseract is vendored.
+ */
+
+func init() {
+	ocrEngineConstructors[OCR_ENGINE_GOSSERACT] = func(fixtureDirectory string) OCREngine {
+		return gosseractEngine{}
+	}
+}
+
+//OCREngine calling libtesseract in process through gosseract instead of running the tesseract command.
+type gosseractEngine struct{}
+
+func (gosseractEngine) Recognize(imagePath string, img image.Image, wl OCRWhiteListType) (result OCRResult, err error) {
+	var configWlFilename string
+	if configWlFilename, err = tesseractConfigFilename(wl); err != nil {
+		return
+	}
+
+	client := gosseract.NewClient()
+	defer client.Close()
+
+	//Use same whitelist config file as tesseract command line
+	if err = client.SetConfigFile(filepath.Join(TESS_CONFIGFILE_DIRECTORY, configWlFilename) + "." + TESS_CONFIGFILE_EXTENSION); err != nil {
+		return
+	}
+	if err = client.SetPageSegMode(gosseract.PSM_AUTO); err != nil {
+		return
+	}
+	if img != nil {
+		var imageData []byte
+		if imageData, err = encodePNG(img); err != nil {
+			return
+		}
+		if err = client.SetImageFromBytes(imageData); err != nil {
+			return
+		}
+	} else if err = client.SetImage(imagePath); err != nil {
+		return
+	}
+
+	if result.PlainText, err = client.Text(); err != nil {
+		return
+	}
+	if result.HOCRText, err = client.HOCRText(); err != nil {
+		return
+	}
+
+	result.Words, err = parseHOCRWords(result.HOCRText)
+	return
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//OCR engine recognizing text in an image.
+type OCREngine interface {
+	//Recognize text in image restricted to whitelist wl. Return plain text, hOCR text and word boxes.
+	//img is the image in memory. If nil, image is read from imagePath. imagePath always names the image for recorded fixtures.
+	Recognize(imagePath string, img image.Image, wl OCRWhiteListType) (result OCRResult, err error)
+}
+
+//OCREngine used by doOCRForSlide. Set with -ocrEngine flag.
+var ocrEngine OCREngine = tesseractCLIEngine{}
+
+//OCREngine constructors keyed by -ocrEngine flag name. fixtureDirectory is the -ocrFixtures flag value.
+var ocrEngineConstructors = map[string]func(fixtureDirectory string) OCREngine{
+	OCR_ENGINE_TESSERACT: func(fixtureDirectory string) OCREngine {
+		return tesseractCLIEngine{}
+	},
+	OCR_ENGINE_REPLAY: func(fixtureDirectory string) OCREngine {
+		return replayOCREngine{Directory: fixtureDirectory}
+	},
+}
+
+//Create OCREngine for name. If record is true, engine results are also written as fixtures to fixtureDirectory for later replay.
+func newOCREngine(name string, fixtureDirectory string, record bool) (engine OCREngine, err error) {
+	constructor, ok := ocrEngineConstructors[name]
+	if !ok {
+		err = fmt.Errorf("Unknown OCR engine %v.", name)
+		return
+	}
+	engine = constructor(fixtureDirectory)
+
+	if record {
+		if name == OCR_ENGINE_REPLAY {
+			err = fmt.Errorf("Cannot record fixtures from %v OCR engine.", name)
+			return
+		}
+		engine = recordingOCREngine{
+			Engine:    engine,
+			Directory: fixtureDirectory}
+	}
+	return
+}
+
+//Return tesseract config filename (without extension) for whitelist type
+func tesseractConfigFilename(wl OCRWhiteListType) (configWlFilename string, err error) {
+	switch wl {
+	case OCR_WHITELIST_NORMAL:
+		configWlFilename = TESS_CONFIGFILE_NORMAL_FILENAME
+	case OCR_WHITELIST_SA:
+		configWlFilename = TESS_CONFIGFILE_SA_FILENAME
+	default:
+		err = fmt.Errorf("Unknown white list type %v", wl)
+	}
+	return
+}
+
+/*
+ * Tesseract command line OCREngine
+ */
+
+//OCREngine running tesseract command line once for plain text and once for hOCR.
+type tesseractCLIEngine struct{}
+
+func (tesseractCLIEngine) Recognize(imageFilepath string, img image.Image, wl OCRWhiteListType) (result OCRResult, err error) {
+	var configWlFilename string
+	if configWlFilename, err = tesseractConfigFilename(wl); err != nil {
+		return
+	}
+
+	//Pass image in memory to tesseract through stdin as PNG
+	var imageData []byte
+	if img != nil {
+		if imageData, err = encodePNG(img); err != nil {
+			return
+		}
+		imageFilepath = TESS_STDIN
+	}
+
+	//Write tesseract output to a directory unique to this OCR call so that concurrently processed photos do not read each other's output.
+	var outputDirectory string
+	if outputDirectory, err = ioutil.TempDir("", TESS_OUTPUT_DIRECTORY_PREFIX); err != nil {
+		return
+	}
+	defer os.RemoveAll(outputDirectory)
+	outputBase := filepath.Join(outputDirectory, TESS_OUTPUTBASE)
+
+	configFileTextPath := filepath.Join(TESS_CONFIGFILE_DIRECTORY, configWlFilename) + "." + TESS_CONFIGFILE_EXTENSION
+	outputTextFilename := outputBase + "." + TESS_OUTPUT_TXT_EXTENSION
+
+	configFileHOCRPath := filepath.Join(TESS_CONFIGFILE_DIRECTORY, configWlFilename) + TESS_CONFIGFILE_HOCR_FILENAME + "." + TESS_CONFIGFILE_EXTENSION
+	outputHOCRFilename := outputBase + "." + TESS_OUTPUT_HOCR_EXTENSION
+
+	//Define the OCR operations we will be performing
+	type OCROperation struct {
+		ConfigFile   string
+		OutputFile   string
+		OutputString *string
+	}
+
+	//Create OCR operations of plaintext and HOCR
+	var ocrOps []OCROperation
+	ocrOps = []OCROperation{
+		OCROperation{
+			ConfigFile:   configFileTextPath,
+			OutputFile:   outputTextFilename,
+			OutputString: &result.PlainText},
+		OCROperation{
+			ConfigFile:   configFileHOCRPath,
+			OutputFile:   outputHOCRFilename,
+			OutputString: &result.HOCRText}}
+
+	for _, ocrOp := range ocrOps {
+		/*
+		 * tesseract imagename|stdin outputbase|stdout [options...] [configfile...]
+		 * ex: tesseract test_images/p1.jpeg output --psm 3 --oem 3 osmtype1.config
+		 */
+		var args []string
+		cmd := "tesseract"
+
+		args = []string{imageFilepath, outputBase, "--psm", "3", "--oem", "3", ocrOp.ConfigFile}
+		command := exec.Command(cmd, args...)
+		if imageData != nil {
+			command.Stdin = bytes.NewReader(imageData)
+		}
+		if err = command.Run(); err != nil {
+			return
+		}
+
+		var ocrBytes []byte
+		if ocrBytes, err = ioutil.ReadFile(ocrOp.OutputFile); err != nil {
+			return
+		}
+		*ocrOp.OutputString = string(ocrBytes)
+	}
+
+	result.Words, err = parseHOCRWords(result.HOCRText)
+	return
+}
+
+/*
+ * Fixture replay OCREngine
+ * Fixtures are stored as <directory>/<image path>.<whitelist config name>.txt|hocr
+ */
+
+//OCREngine returning recorded tesseract output fixtures instead of running OCR. Allows slide processing to run without tesseract installed.
+type replayOCREngine struct {
+	Directory string
+}
+
+//Return fixture path without txt/hocr extension for image and whitelist.
+func ocrFixtureBasePath(directory string, imagePath string, wl OCRWhiteListType) (fixtureBase string, err error) {
+	var configWlFilename string
+	if configWlFilename, err = tesseractConfigFilename(wl); err != nil {
+		return
+	}
+	fixtureBase = filepath.Join(directory, filepath.Clean(imagePath)) + "." + configWlFilename
+	return
+}
+
+//Fixtures are found by imagePath. img is not read.
+func (r replayOCREngine) Recognize(imagePath string, img image.Image, wl OCRWhiteListType) (result OCRResult, err error) {
+	var fixtureBase string
+	if fixtureBase, err = ocrFixtureBasePath(r.Directory, imagePath, wl); err != nil {
+		return
+	}
+
+	var plainText, hocrText []byte
+	if plainText, err = ioutil.ReadFile(fixtureBase + "." + TESS_OUTPUT_TXT_EXTENSION); err != nil {
+		return
+	}
+	if hocrText, err = ioutil.ReadFile(fixtureBase + "." + TESS_OUTPUT_HOCR_EXTENSION); err != nil {
+		return
+	}
+
+	result.PlainText = string(plainText)
+	result.HOCRText = string(hocrText)
+	result.Words, err = parseHOCRWords(result.HOCRText)
+	return
+}
+
+//OCREngine wrapper writing results of Engine as fixtures for replayOCREngine.
+type recordingOCREngine struct {
+	Engine    OCREngine
+	Directory string
+}
+
+func (r recordingOCREngine) Recognize(imagePath string, img image.Image, wl OCRWhiteListType) (result OCRResult, err error) {
+	if result, err = r.Engine.Recognize(imagePath, img, wl); err != nil {
+		return
+	}
+
+	var fixtureBase string
+	if fixtureBase, err = ocrFixtureBasePath(r.Directory, imagePath, wl); err != nil {
+		return
+	}
+	if err = os.MkdirAll(filepath.Dir(fixtureBase), os.ModePerm); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(fixtureBase+"."+TESS_OUTPUT_TXT_EXTENSION, []byte(result.PlainText), 0644); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(fixtureBase+"."+TESS_OUTPUT_HOCR_EXTENSION, []byte(result.HOCRText), 0644); err != nil {
+		return
+	}
+	return
+}
+
+/*
+ * hOCR parsing
+ */
+
+//Regex for bbox and optional confidence (x_wconf) in hOCR title attribute
+var hocrBBoxRegex = regexp.MustCompile("bbox ([0-9]+) ([0-9]+) ([0-9]+) ([0-9]+)(?:;.*x_wconf ([0-9]+))?")
+
+//Parse hOCR document into OCRWord slice in document order.
+//LineId and BlockId are the ids of the enclosing ocr_line and ocr_carea elements.
+func parseHOCRWords(hocr string) (words []OCRWord, err error) {
+	decoder := xml.NewDecoder(strings.NewReader(hocr))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var blockId, lineId string
+
+	//Depth of element nesting and depth of current ocrx_word element. 0 when not inside a word.
+	var depth, wordDepth int
+	var currentWord OCRWord
+	var currentText strings.Builder
+
+	for {
+		var token xml.Token
+		if token, err = decoder.Token(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			depth++
+
+			var class, id, title string
+			for _, attr := range t.Attr {
+				switch attr.Name.Local {
+				case "class":
+					class = attr.Value
+				case "id":
+					id = attr.Value
+				case "title":
+					title = attr.Value
+				}
+			}
+
+			switch class {
+			case "ocr_carea":
+				blockId = id
+			case "ocr_line", "ocr_caption", "ocr_header", "ocr_textfloat":
+				lineId = id
+			case "ocrx_word":
+				wordDepth = depth
+				currentText.Reset()
+				currentWord = OCRWord{
+					LineId:  lineId,
+					BlockId: blockId}
+
+				bboxMatch := hocrBBoxRegex.FindStringSubmatch(title)
+				if bboxMatch == nil {
+					err = fmt.Errorf("No bbox found in hOCR word %v title %v", id, title)
+					return
+				}
+
+				var coords [4]int
+				for i := range coords {
+					if coords[i], err = strconv.Atoi(bboxMatch[i+1]); err != nil {
+						return
+					}
+				}
+				currentWord.BBox = image.Rect(coords[0], coords[1], coords[2], coords[3])
+
+				//Words without confidence are treated as fully confident
+				currentWord.Confidence = 100
+				if len(bboxMatch[5]) > 0 {
+					if currentWord.Confidence, err = strconv.Atoi(bboxMatch[5]); err != nil {
+						return
+					}
+				}
+			}
+		case xml.CharData:
+			//Text inside word including text in nested <strong>/<em> elements
+			if wordDepth > 0 {
+				currentText.Write(t)
+			}
+		case xml.EndElement:
+			if wordDepth > 0 && depth == wordDepth {
+				currentWord.Text = strings.TrimSpace(currentText.String())
+				if len(currentWord.Text) > 0 {
+					words = append(words, currentWord)
+				}
+				wordDepth = 0
+			}
+			depth--
+		}
+	}
+	return
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+//Stub tesseract command writing the input image path as plain text and as a single hOCR word.
+//tesseract imagename outputbase --psm 3 --oem 3 configfile
+const stubTesseractScript = `#!/bin/sh
+sleep 0.05
+case "$7" in
+*_hocr.config)
+	printf '<div class="ocr_page"><span class="ocr_line" id="line_1"><span class="ocrx_word" id="word_1" title="bbox 0 0 10 10; x_wconf 90">%s</span></span></div>' "$1" > "$2.hocr";;
+*)
+	printf '%s' "$1" > "$2.txt";;
+esac
+`
+
+//Put stub tesseract first in PATH. Call returned restore function to undo.
+func installStubTesseract(t *testing.T) (restore func()) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub tesseract requires sh")
+	}
+
+	binDirectory, err := ioutil.TempDir("", "spacea_stub_tesseract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(binDirectory, "tesseract"), []byte(stubTesseractScript), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", binDirectory+string(os.PathListSeparator)+path)
+	return func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(binDirectory)
+	}
+}
+
+//Slides OCR'd in parallel must each read back the output for their own image.
+func TestDoOCRForSlideConcurrent(t *testing.T) {
+	defer installStubTesseract(t)()
+
+	previousEngine := ocrEngine
+	ocrEngine = tesseractCLIEngine{}
+	defer func() { ocrEngine = previousEngine }()
+
+	const slideCount = 16
+	slides := make([]Slide, slideCount)
+	for i := range slides {
+		slides[i] = Slide{
+			SaveType:  SAVE_IMAGE_TRAINING,
+			Terminal:  Terminal{Title: "Test Terminal"},
+			FBNodeId:  fmt.Sprintf("photo%v", i),
+			Extension: "png"}
+	}
+
+	var wg sync.WaitGroup
+	for i := range slides {
+		wg.Add(1)
+		go func(s *Slide) {
+			defer wg.Done()
+			if err := doOCRForSlide(s, OCR_WHITELIST_NORMAL); err != nil {
+				t.Errorf("doOCRForSlide %v: %v", s.FBNodeId, err)
+			}
+		}(&slides[i])
+	}
+	wg.Wait()
+
+	for _, s := range slides {
+		expected := photoPath(s)
+		if s.PlainText != expected {
+			t.Errorf("%v plain text is %q, expected %q", s.FBNodeId, s.PlainText, expected)
+		}
+		if len(s.Words) != 1 || s.Words[0].Text != expected {
+			t.Errorf("%v words are %v, expected one word %q", s.FBNodeId, s.Words, expected)
+		}
+	}
+}
@@ -1,35 +1,83 @@
 package main
 
 import (
-	"errors"
 	"fmt"
-	"github.com/jbowtie/gokogiri/html"
-	"github.com/jbowtie/gokogiri/xml"
-	//"github.com/otiai10/gosseract"
 	"github.com/sajari/fuzzy"
 	"image"
-	"io/ioutil"
 	"log"
-	"os/exec"
-	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 )
 
 var fuzzyModelForKeyword map[string]*fuzzy.Model
 
 var locationKeywordMap map[string]string
+
+//Spelled out base designators used to add phrase keywords. ex: JB Pearl Harbor-Hickam -> joint base pearl harbor hickam
+var locationDesignatorExpansions = map[string]string{
+	"aaf":  "army airfield",
+	"ab":   "air base",
+	"afb":  "air force base",
+	"ang":  "air national guard",
+	"angb": "air national guard base",
+	"arb":  "air reserve base",
+	"ars":  "air reserve station",
+	"iap":  "international airport",
+	"jb":   "joint base",
+	"mcas": "marine corps air station",
+	"nas":  "naval air station",
+	"ns":   "naval station",
+	"nsa":  "naval support activity",
+	"raf":  "royal air force"}
+
+//Uppercase ICAO/IATA code -> terminal title. Codes are matched exactly, not with fuzzy models.
+var locationCodeMap map[string]string
 var fuzzyModelByDepth map[int]*fuzzy.Model
 var fuzzyBannedSpellings map[string]int
 
+//Number of users holding fuzzy models through acquireFuzzyModels
+var fuzzyModelsUsers int
+var fuzzyModelsLock sync.Mutex
+
+//Create fuzzy models if no other user holds them. Call releaseFuzzyModels when done.
+//Allows periodic terminal update and photo job worker to share fuzzy models.
+func acquireFuzzyModels() (err error) {
+	fuzzyModelsLock.Lock()
+	defer fuzzyModelsLock.Unlock()
+
+	if fuzzyModelsUsers == 0 {
+		if err = createFuzzyModels(); err != nil {
+			return
+		}
+	}
+	fuzzyModelsUsers++
+	return
+}
+
+//Destroy fuzzy models when last user releases them.
+func releaseFuzzyModels() {
+	fuzzyModelsLock.Lock()
+	defer fuzzyModelsLock.Unlock()
+
+	fuzzyModelsUsers--
+	if fuzzyModelsUsers <= 0 {
+		fuzzyModelsUsers = 0
+		destroyFuzzyModels()
+	}
+}
+
 //Destroy fuzzy models
 func destroyFuzzyModels() {
 	fuzzyModelForKeyword = nil
 	locationKeywordMap = nil
+	locationCodeMap = nil
 	fuzzyModelByDepth = nil
 	fuzzyBannedSpellings = nil
+	aircraftKeywordMap = nil
+	fuzzyModelForAircraft = nil
 }
 
 //Create fuzzy models for slide labels and terminal keywords
@@ -113,6 +161,9 @@ func createFuzzyModels() (err error) {
 	}
 	createFuzzyModelsForKeywords(keywordList, &fuzzyModelForKeyword)
 
+	//Create fuzzy model for aircraft and mission names
+	createAircraftFuzzyModel()
+
 	//Create fuzzy models for terminal keywords
 	var locationKeywordsArray []Terminal
 	if locationKeywordsArray, err = readTerminalArrayFromFiles(TERMINAL_FILE, LOCATION_KEYWORDS_FILE); err != nil {
@@ -120,6 +171,7 @@ func createFuzzyModels() (err error) {
 	}
 
 	locationKeywordMap = make(map[string]string)
+	locationCodeMap = make(map[string]string)
 	fuzzyModelByDepth = make(map[int]*fuzzy.Model)
 
 	//Add keyword to locationKeywordMap and fuzzyModelByDepth
@@ -130,7 +182,8 @@ func createFuzzyModels() (err error) {
 			return
 		}
 
-		keyword = strings.ToLower(keyword)
+		//Separate words the same way OCR text is split so phrases match OCR word windows. ex: harbor-hickam -> harbor hickam
+		keyword = strings.Join(strings.FieldsFunc(strings.ToLower(keyword), isOCRWordSeparator), " ")
 
 		if len(keyword) < FUZZY_MODEL_KEYWORD_MIN_LENGTH {
 			err = fmt.Errorf("Keyword length less than %v. %v", FUZZY_MODEL_KEYWORD_MIN_LENGTH, keyword)
@@ -191,136 +244,56 @@ func createFuzzyModels() (err error) {
 			}
 		}
 
+		//Add phrases of full title, title with designators spelled out and title without designators
+		for _, phrase := range locationPhrasesForTitle(v.Title) {
+			addKeyword(phrase, v.Title)
+		}
+
 		//Add special keywords
 		for _, k := range v.Keywords {
 			addKeyword(k, v.Title)
 		}
+
+		//Add airport codes. Lowercase code is also added to locationKeywordMap so code matches resolve to a title like keywords.
+		for _, code := range []string{v.ICAO, v.IATA} {
+			if len(code) == 0 {
+				continue
+			}
+			code = strings.ToUpper(code)
+			if title, ok := locationCodeMap[code]; ok && title != v.Title {
+				fmt.Printf("Airport code %v of %v already used by %v.\n", code, v.Title, title)
+				continue
+			}
+			locationCodeMap[code] = v.Title
+			locationKeywordMap[strings.ToLower(code)] = v.Title
+		}
 	}
 
 	return
 }
 
-//Perform OCR on file for slide and set s.PlainText and s.HOCRText
+//Perform OCR on file for slide with ocrEngine and set s.PlainText, s.HOCRText and s.Words
 func doOCRForSlide(s *Slide, wl OCRWhiteListType) (err error) {
-
-	imageFilepath := photoPath(*s)
-
-	var configWlFilename string
-	switch wl {
-	case OCR_WHITELIST_NORMAL:
-		configWlFilename = TESS_CONFIGFILE_NORMAL_FILENAME
-		break
-	case OCR_WHITELIST_SA:
-		configWlFilename = TESS_CONFIGFILE_SA_FILENAME
-		break
-	default:
-		log.Fatal("Unknown white list type ", wl)
-	}
-
-	configFileTextPath := filepath.Join(TESS_CONFIGFILE_DIRECTORY, configWlFilename) + "." + TESS_CONFIGFILE_EXTENSION
-	outputTextFilename := TESS_OUTPUTBASE + "." + TESS_OUTPUT_TXT_EXTENSION
-
-	configFileHOCRPath := filepath.Join(TESS_CONFIGFILE_DIRECTORY, configWlFilename) + TESS_CONFIGFILE_HOCR_FILENAME + "." + TESS_CONFIGFILE_EXTENSION
-	outputHOCRFilename := TESS_OUTPUTBASE + "." + TESS_OUTPUT_HOCR_EXTENSION
-
-	//Define the OCR operations we will be performing
-	type OCROperation struct {
-		ConfigFile   string
-		OutputFile   string
-		OutputString *string
+	var result OCRResult
+	if result, err = ocrEngine.Recognize(photoPath(*s), s.Image, wl); err != nil {
+		return
 	}
 
-	//Create OCR operations of plaintext and HOCR
-	var ocrOps []OCROperation
-	ocrOps = []OCROperation{
-		OCROperation{
-			ConfigFile:   configFileTextPath,
-			OutputFile:   outputTextFilename,
-			OutputString: &(*s).PlainText},
-		OCROperation{
-			ConfigFile:   configFileHOCRPath,
-			OutputFile:   outputHOCRFilename,
-			OutputString: &(*s).HOCRText}}
-
-	for _, ocrOp := range ocrOps {
-		/*
-		 * tesseract imagename|stdin outputbase|stdout [options...] [configfile...]
-		 * ex: tesseract test_images/p1.jpeg output --psm 3 --oem 3 osmtype1.config
-		 */
-		var args []string
-		cmd := "tesseract"
-
-		//Get plaintext
-		args = []string{imageFilepath, TESS_OUTPUTBASE, "--psm", "3", "--oem", "3", ocrOp.ConfigFile}
-		if err = exec.Command(cmd, args...).Run(); err != nil {
-			return
+	//Scale word boxes of enlarged variants back to original image coordinates
+	if scale := s.imageScale(); scale > 1 {
+		for i := range result.Words {
+			result.Words[i].BBox = image.Rect(result.Words[i].BBox.Min.X/scale, result.Words[i].BBox.Min.Y/scale, result.Words[i].BBox.Max.X/scale, result.Words[i].BBox.Max.Y/scale)
 		}
-
-		var ocrBytes []byte
-		if ocrBytes, err = ioutil.ReadFile(ocrOp.OutputFile); err != nil {
-			return
-		}
-		*ocrOp.OutputString = string(ocrBytes)
 	}
 
-	/*
-		var args []string
-
-		//Get plaintext
-		args = []string{imageFilepath, TESS_OUTPUTBASE, "--psm", "3", "--oem", "3", configFileTextPath}
-		if err = exec.Command(cmd, args...).Run(); err != nil {
-			return
-		}
-
-		if (*s).PlainText, err = ioutil.ReadFile(outputTextFilename); err != nil {
-			return
-		}
-
-		//Get HOCR text
-		args = []string{imageFilepath, TESS_OUTPUTBASE, "--psm", "3", "--oem", "3", configFileHOCRPath}
-		if err = exec.Command(cmd, args...).Run(); err != nil {
-			return
-		}
-
-		if (*s).HOCRText, err = ioutil.ReadFile(outputHOCRFilename); err != nil {
-			return
-		}
-	*/
-
-	/*
-
-		client := gosseract.NewClient()
-		defer client.Close()
-		client.SetPageSegMode(gosseract.PSM_AUTO) //C++ API may have different PSM than command line. https://groups.google.com/d/msg/tesseract-ocr/bD1zJNiDubY/kb7NZPIV38AJ
-		client.SetImage(filepath)
-
-		switch wl {
-		case OCR_WHITELIST_NORMAL:
-			client.SetWhitelist("1234567890abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ,:=().*-/")
-			break
-		case OCR_WHITELIST_SA:
-			client.SetWhitelist("1234567890TFSP")
-			break
-		default:
-			log.Fatal("Unknown white list type ", wl)
-		}
-
-		if (*s).PlainText, err = client.Text(); err != nil {
-			return
-		} else if len((*s).PlainText) == 0 {
-			//displayMessageForSlide((*s), fmt.Sprintf("No plain text extracted from slide"))
-		}
-
-		if (*s).HOCRText, err = client.HOCRText(); err != nil {
-			return
-		} else if len((*s).HOCRText) == 0 {
-			//displayMessageForSlide(*s, fmt.Sprintf("No hOCR text extracted from slide"))
-		}
-	*/
+	(*s).PlainText = result.PlainText
+	(*s).HOCRText = result.HOCRText
+	(*s).Words = result.Words
 	return
 }
 
-//Find closest spelling of keyword for multiple slides (processed versions)
+//Find closest spelling of keyword for multiple slides (any number of image variants)
+//Ties are resolved in favor of the earlier slide so terminal variant order sets priority.
 func findKeywordClosestSpellingInPhotoInSaveImageTypes(keyword string, slides []Slide) (closestSpelling string, closestSpellingSlide Slide, err error) {
 	//Store closest spelling of keyword for each image processing type
 	var closestKeywordSpellings []string
@@ -339,6 +312,11 @@ func findKeywordClosestSpellingInPhotoInSaveImageTypes(keyword string, slides []
 
 	var closestSpellingDistance int
 	for i, v := range closestKeywordSpellings {
+		//Skip variants where keyword was not found
+		if len(v) == 0 {
+			continue
+		}
+
 		distance := fuzzy.Levenshtein(&v, &keyword)
 		if len(closestSpelling) == 0 && len(v) > 0 {
 			closestSpelling = v
@@ -380,9 +358,7 @@ func findKeywordClosestSpellingInPlainText(keyword string, plainText string) (cl
 	*/
 
 	//Split by the special characters in our whitelist including \r and \n
-	ocrWords := strings.FieldsFunc(plainText, func(c rune) bool {
-		return c == ' ' || c == '\n' || c == '\r' || c == ',' || c == ':' || c == '=' || c == '(' || c == ')' || c == '.' || c == '*' || c == '-' || c == '/'
-	})
+	ocrWords := strings.FieldsFunc(plainText, isOCRWordSeparator)
 
 	var closestSpellingDistance int
 	for _, ocrWord := range ocrWords {
@@ -405,237 +381,208 @@ func findKeywordClosestSpellingInPlainText(keyword string, plainText string) (cl
 	return
 }
 
-//Find all best terminal keyword matches for every word in plaintext. Return map[spelling]TerminalKeywordsResult{Keyword, Distance}
-func findTerminalKeywordsInPlainText(plainText string) (found map[string]TerminalKeywordsResult) {
+//Find all best terminal keyword matches for every word and phrase in plaintext. Return map[spelling]TerminalKeywordsResult{Keyword, Distance}
+func findTerminalKeywordsInPlainText(plainText string) (found map[string]TerminalKeywordsResult, err error) {
 	found = make(map[string]TerminalKeywordsResult)
 
-	//lowercase keyword and plaintext
-	plainText = strings.ToLower(plainText)
-	//log.Println(plainText)
-
-	//Split by the special characters in our whitelist including \r and \n
-	ocrWords := strings.FieldsFunc(plainText, func(c rune) bool {
-		return c == ' ' || c == '\n' || c == '\r' || c == ',' || c == ':' || c == '=' || c == '(' || c == ')' || c == '.' || c == '*' || c == '-' || c == '/'
-	})
-
-	//Search single word
-	for _, ocrWord := range ocrWords {
-
-		//If found spelling exists in spelling ban list, skip it
-		if _, ok := fuzzyBannedSpellings[ocrWord]; ok == true {
+	//Exact match airport codes written in uppercase before fuzzy search. ex: KDOV, RMS
+	//Lowercase codes are skipped since 3 letter codes are often ordinary words.
+	//Codes must be a whole OCR word on a line that is not a date header. ex: THU in "THU 28 MAR" is not Thule
+	for _, line := range strings.Split(plainText, "\n") {
+		if isDateHeaderLine(line) {
 			continue
 		}
-
-		//Find best match from all fuzzy models for current word
-		var closestSuggestion string
-		var closestSpelling string
-		var closestSpellingDistance int
-		for depth, fuzzyModel := range fuzzyModelByDepth {
-			if fuzzyModel == nil {
-				log.Fatal("No fuzzy model for depth %v", depth)
+		for _, ocrWord := range strings.Fields(line) {
+			ocrWord = strings.TrimFunc(ocrWord, isOCRWordSeparator)
+			if ocrWord != strings.ToUpper(ocrWord) {
+				continue
 			}
+			if _, ok := locationCodeMap[ocrWord]; ok {
+				code := strings.ToLower(ocrWord)
+				found[code] = TerminalKeywordsResult{Keyword: code, Distance: 0}
+			}
+		}
+	}
 
-			for _, suggestion := range fuzzyModel.Suggestions(ocrWord, true) {
+	//lowercase keyword and plaintext
+	plainText = strings.ToLower(plainText)
+	//log.Println(plainText)
 
-				distance := fuzzy.Levenshtein(&ocrWord, &suggestion)
-				if len(closestSpelling) == 0 && len(ocrWord) > 0 {
-					closestSuggestion = suggestion
-					closestSpelling = ocrWord
-					closestSpellingDistance = distance
-				} else if distance < closestSpellingDistance {
-					closestSuggestion = suggestion
-					closestSpelling = ocrWord
-					closestSpellingDistance = distance
+	//Search windows of 1 to FUZZY_PHRASE_MAX_WORDS words starting at each word of a line. Closest window is kept and longer windows win ties so the phrase is found as one spelling.
+	//Words in a found window are not searched again. ex: "jb pearl harbor hickam" instead of "pearl" and "hickam"
+	for _, line := range strings.Split(plainText, "\n") {
+		//Split by the special characters in our whitelist including \r
+		lineWords := strings.FieldsFunc(line, isOCRWordSeparator)
+
+		for i := 0; i < len(lineWords); {
+			var closestResult TerminalKeywordsResult
+			var closestSpelling string
+			windowWords := 1
+			for n := 1; n <= FUZZY_PHRASE_MAX_WORDS && i+n <= len(lineWords); n++ {
+				spelling := strings.Join(lineWords[i:i+n], " ")
+				var result TerminalKeywordsResult
+				var ok bool
+				if result, ok, err = closestTerminalKeyword(spelling); err != nil {
+					return
+				}
+				if ok && (len(closestSpelling) == 0 || result.Distance <= closestResult.Distance) {
+					closestResult = result
+					closestSpelling = spelling
+					windowWords = n
 				}
 			}
-		}
 
-		/*
-			//Unneeded because we are running each spelling against all fuzzy models so optimal suggestion will be found the first time.
-			//Find best match of all found suggestions (keywords)
-			if len(closestSuggestion) > 0 && found[closestSpelling] != nil && closestSpellingDistance < found[closestSpelling].Distance  {
-				found[closestSpelling] = new TerminalKeyWordsResult{closestSuggestion, closestSpellingDistance}
+			//Add to found spelling map
+			if _, ok := found[closestSpelling]; !ok && len(closestSpelling) > 0 {
+				found[closestSpelling] = closestResult
 			}
-		*/
-
-		//Add to found spelling map
-		_, ok := found[closestSpelling]
-		if !ok && len(closestSuggestion) > 0 {
-			tmp := TerminalKeywordsResult{Keyword: closestSuggestion, Distance: closestSpellingDistance}
-			found[closestSpelling] = tmp
+			i += windowWords
 		}
 	}
 
-	//Search two words
-	var prevWord string
-	for _, ocrWord := range ocrWords {
-		if len(prevWord) == 0 {
-			prevWord = ocrWord
+	//log.Println(found)
+	return
+}
+
+//Return true if line has a number and either a month name or starts with a weekday name. ex: THU 28 MAR, Friday 29
+//Weekday names later in the line may be airport codes. ex: 0800 THU 10T
+func isDateHeaderLine(line string) bool {
+	var hasName, hasNumber bool
+	for i, token := range strings.FieldsFunc(strings.ToLower(line), isOCRWordSeparator) {
+		if unicode.IsDigit([]rune(token)[0]) {
+			hasNumber = true
 			continue
 		}
-
-		prevLength := len(prevWord)
-		curLength := len(ocrWord)
-		twoWord := fmt.Sprintf("%v %v", prevWord, ocrWord)
-
-		//log.Println(twoWord)
-
-		//If word exists in spelling ban list, ignore it
-		if _, ok := fuzzyBannedSpellings[twoWord]; ok == true {
+		if len(token) < 3 {
 			continue
 		}
+		for day := time.Sunday; i == 0 && day <= time.Saturday && !hasName; day++ {
+			hasName = strings.HasPrefix(strings.ToLower(day.String()), token)
+		}
+		for month := time.January; month <= time.December && !hasName; month++ {
+			hasName = strings.HasPrefix(strings.ToLower(month.String()), token)
+		}
+	}
+	return hasName && hasNumber
+}
 
-		//Find best match from all fuzzy models for current word
-		var closestSuggestion string
-		var closestSpelling string
-		var closestSpellingDistance int
-		for depth, fuzzyModel := range fuzzyModelByDepth {
-			if fuzzyModel == nil {
-				log.Fatal("No fuzzy model for depth %v", depth)
-			}
-
-			for _, suggestion := range fuzzyModel.Suggestions(twoWord, true) {
-				distance := fuzzy.Levenshtein(&twoWord, &suggestion)
-				if len(closestSpelling) == 0 && len(twoWord) > 0 {
-					closestSuggestion = suggestion
-
-					if prevLength >= curLength {
-						closestSpelling = prevWord
-					} else {
-						closestSpelling = ocrWord
-					}
-
-					closestSpellingDistance = distance
-				} else if distance < closestSpellingDistance {
-					closestSuggestion = suggestion
-
-					if prevLength >= curLength {
-						closestSpelling = prevWord
-					} else {
-						closestSpelling = ocrWord
-					}
+//Find best match from all fuzzy models for spelling. ok is false if spelling is banned or no keyword is close.
+//Return error if a fuzzy model is missing.
+func closestTerminalKeyword(spelling string) (closest TerminalKeywordsResult, ok bool, err error) {
+	//If found spelling exists in spelling ban list, skip it
+	if _, banned := fuzzyBannedSpellings[spelling]; banned || len(spelling) == 0 {
+		return
+	}
 
-					closestSpellingDistance = distance
-				}
-			}
+	for depth, fuzzyModel := range fuzzyModelByDepth {
+		if fuzzyModel == nil {
+			err = fmt.Errorf("No fuzzy model for depth %v", depth)
+			return
 		}
 
-		/*
-			//Unneeded because we are running each spelling against all fuzzy models so optimal suggestion will be found the first time.
-			//Find best match of all found suggestions (keywords)
-			if len(closestSuggestion) > 0 && found[closestSpelling] != nil && closestSpellingDistance < found[closestSpelling].Distance  {
-				found[closestSpelling] = new TerminalKeyWordsResult{closestSuggestion, closestSpellingDistance}
+		for _, suggestion := range fuzzyModel.Suggestions(spelling, true) {
+			distance := fuzzy.Levenshtein(&spelling, &suggestion)
+			if !ok || distance < closest.Distance {
+				closest = TerminalKeywordsResult{Keyword: suggestion, Distance: distance}
+				ok = true
 			}
-		*/
-
-		//Add to found spelling map
-		_, ok := found[closestSpelling]
-		if !ok && len(closestSuggestion) > 0 {
-			tmp := TerminalKeywordsResult{Keyword: closestSuggestion, Distance: closestSpellingDistance}
-			found[closestSpelling] = tmp
 		}
-
-		prevWord = ocrWord
 	}
-
-	//log.Println(found)
 	return
-
 }
 
-func getTextBounds(hocr string, textSpelling string) (bboxes []image.Rectangle, err error) {
-	var doc *html.HtmlDocument
-	doc, err = html.Parse([]byte(hocr), xml.DefaultEncodingBytes, nil, xml.DefaultParseOption, xml.DefaultEncodingBytes)
-	if err != nil {
-		return
+//Parenthesized command in terminal title. ex: (AETC)
+var locationTitleParensRegex = regexp.MustCompile("\\([^),]*\\)?")
+
+//Return multi word phrases for terminal title of at most FUZZY_PHRASE_MAX_WORDS words. Parenthesized text is left out.
+//ex: "NS Rota, Spain" -> ns rota spain, naval station rota, naval station rota spain
+//ex: "JB Pearl Harbor-Hickam, Hawaii" -> joint base pearl harbor hickam, pearl harbor hickam, pearl harbor hickam hawaii
+func locationPhrasesForTitle(title string) (phrases []string) {
+	parts := strings.Split(locationTitleParensRegex.ReplaceAllString(strings.ToLower(title), " "), ",")
+	nameWords := strings.FieldsFunc(parts[0], isOCRWordSeparator)
+	placeWords := strings.FieldsFunc(strings.Join(parts[1:], " "), isOCRWordSeparator)
+
+	var expandedWords, strippedWords []string
+	for _, w := range nameWords {
+		if expansion, ok := locationDesignatorExpansions[w]; ok {
+			expandedWords = append(expandedWords, expansion)
+		} else {
+			expandedWords = append(expandedWords, w)
+			strippedWords = append(strippedWords, w)
+		}
 	}
-
-	if doc.Root() == nil || doc.Root().CountChildren() == 0 {
-		err = errors.New("No root node to document or no children to root")
-		return
+	expandedWords = strings.Fields(strings.Join(expandedWords, " "))
+
+	added := make(map[string]bool)
+	for _, words := range [][]string{
+		append(append([]string{}, nameWords...), placeWords...),
+		expandedWords,
+		append(append([]string{}, expandedWords...), placeWords...),
+		strippedWords,
+		append(append([]string{}, strippedWords...), placeWords...)} {
+		phrase := strings.Join(words, " ")
+		if len(words) < 2 || len(words) > FUZZY_PHRASE_MAX_WORDS || added[phrase] {
+			continue
+		}
+		added[phrase] = true
+		phrases = append(phrases, phrase)
 	}
+	return
+}
 
-	html := doc.Root().FirstChild()
-
-	//XPath query to find ocrx_word element with text node containing target text.
-	var xPathQuery string
-	xPathQuery = fmt.Sprintf("//*[@class='ocrx_word' and contains(translate(text(), '%v', '%v'), '%v')]", strings.ToUpper(textSpelling), strings.ToLower(textSpelling), strings.ToLower(textSpelling))
+//Return true if rune separates words in OCR text. Same separators used to split plain text and OCRWord text so that spellings found in plain text match word boxes exactly.
+func isOCRWordSeparator(c rune) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == ',' || c == ':' || c == '=' || c == '(' || c == ')' || c == '.' || c == '*' || c == '-' || c == '/'
+}
 
-	//XPath query to find parent element of element with text node containing target text. Used when target text enclosed in non hocr element.
-	var xPathQueryParent string
-	xPathQueryParent = fmt.Sprintf("//*[contains(translate(text(), '%v', '%v'), '%v')]/ancestor::span[@class='ocrx_word']", strings.ToUpper(textSpelling), strings.ToLower(textSpelling), strings.ToLower(textSpelling))
+//Token of an OCRWord split by isOCRWordSeparator
+type ocrWordToken struct {
+	Text string
+	Word OCRWord
+}
 
-	//Try to search for .ocrx_word element containing target text.
-	//If no results found, element may be enclosed in <strong> tags, so look for parent.
-	var results []xml.Node
-	if results, err = html.Search(xPathQuery); err != nil {
-		return
-	}
-	if len(results) == 0 {
-		if results, err = html.Search(xPathQueryParent); err != nil {
-			return
+//Split words into lowercase tokens in document order.
+func ocrWordTokens(words []OCRWord) (tokens []ocrWordToken) {
+	for _, w := range words {
+		for _, text := range strings.FieldsFunc(strings.ToLower(w.Text), isOCRWordSeparator) {
+			tokens = append(tokens, ocrWordToken{
+				Text: text,
+				Word: w})
 		}
 	}
+	return
+}
 
-	//If no results found, print xpathquery and write document to file for debugging
-	//Testing http://www.xpathtester.com/xpath/f5f4ce066286d9fdd780998a67d73415
-	if len(results) == 0 {
-		errorText := fmt.Sprintf("No %v found. Xpathquery %v", textSpelling, xPathQuery)
-		//err = fmt.Errorf("No %v found. Xpathquery %v", textSpelling, xPathQuery)
-		ioutil.WriteFile("xpath-debug.html", []byte(fmt.Sprintf("%v", doc)), 0644)
-		//log.Fatal(err)
-
-		//Display OCR issue
-		fmt.Printf("\u001b[1m\u001b[31m%v\u001b[0m\n", errorText)
+//Find bounding boxes of words exactly matching textSpelling (case insensitive) in slide words.
+//textSpelling with multiple tokens matches consecutive tokens on the same line and returns the union of their word boxes.
+//Words with confidence below OCR_WORD_CONFIDENCE_THRESHOLD are skipped.
+func findWordBounds(words []OCRWord, textSpelling string) (bboxes []image.Rectangle) {
+	searchTokens := strings.FieldsFunc(strings.ToLower(textSpelling), isOCRWordSeparator)
+	if len(searchTokens) == 0 {
+		return
 	}
 
-	for _, r := range results {
-		title := r.Attr("title")
-		//fmt.Printf("%v\n", len(results))
-		//fmt.Printf("%v\n", r.String())
-
-		//Regex search for bbox and optional confidence (x_wconf) attr.
-		var bboxRegEx *regexp.Regexp
-		if bboxRegEx, err = regexp.Compile("bbox ([0-9]*) ([0-9]*) ([0-9]*) ([0-9]*);(?: x_wconf )?(\\d\\d)?"); err != nil {
-			return
-		}
-
-		bboxMatch := bboxRegEx.FindStringSubmatch(title)
-
-		if len(bboxMatch) == 0 {
-			err = errors.New("No bbox found in regex")
-			return
-		}
-
-		var minX, minY, maxX, maxY int
-
-		if minX, err = strconv.Atoi(bboxMatch[1]); err != nil {
-			return
-		}
-		if minY, err = strconv.Atoi(bboxMatch[2]); err != nil {
-			return
-		}
-		if maxX, err = strconv.Atoi(bboxMatch[3]); err != nil {
-			return
-		}
-		if maxY, err = strconv.Atoi(bboxMatch[4]); err != nil {
-			return
-		}
-
-		//Check if x_wconf > OCR_WORD_CONFIDENCE_THRESHOLD if word confidence provided by OCR.
-		//Skip element if confidence too low (<threshold)
-		if len(bboxMatch[5]) > 0 {
-			var wconf int
-			if wconf, err = strconv.Atoi(bboxMatch[5]); err != nil {
-				return
-			}
-			if wconf < OCR_WORD_CONFIDENCE_THRESHOLD {
-				continue
+	tokens := ocrWordTokens(words)
+	for i := 0; i+len(searchTokens) <= len(tokens); i++ {
+		var bbox image.Rectangle
+		matched := true
+		for j, searchToken := range searchTokens {
+			token := tokens[i+j]
+			if token.Text != searchToken || token.Word.LineId != tokens[i].Word.LineId || token.Word.Confidence < OCR_WORD_CONFIDENCE_THRESHOLD {
+				matched = false
+				break
 			}
+			bbox = bbox.Union(token.Word.BBox)
 		}
 
-		bboxes = append(bboxes, image.Rectangle{image.Point{minX, minY}, image.Point{maxX, maxY}})
+		if matched {
+			bboxes = append(bboxes, bbox)
+		}
 	}
 
+	//Display OCR issue
+	if len(bboxes) == 0 {
+		fmt.Printf("\u001b[1m\u001b[31mNo %v found in OCR words.\u001b[0m\n", textSpelling)
+	}
 	return
 }
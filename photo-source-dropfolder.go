@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+/*
+ * Local directory "drop folder" PhotoSource
+ * Directory tree is laid out as <directory>/<terminal id>/<image files>
+ */
+
+//PhotoSource reading image files dropped into a local directory tree.
+type dropFolderPhotoSource struct {
+	Directory string
+}
+
+//List image files in the Terminal directory of the drop folder.
+//CreatedTime is the EXIF date of the image if available, otherwise the file modification time.
+func (d dropFolderPhotoSource) RecentPhotos(t Terminal) (photos []SourcePhoto, err error) {
+	terminalDirectory := filepath.Join(d.Directory, t.Id)
+
+	var exist bool
+	if exist, err = exists(terminalDirectory); err != nil || !exist {
+		return
+	}
+
+	var files []os.FileInfo
+	if files, err = ioutil.ReadDir(terminalDirectory); err != nil {
+		return
+	}
+
+	for _, f := range files {
+		if f.IsDir() || !isDropFolderImageFile(f.Name()) {
+			continue
+		}
+
+		var photo SourcePhoto
+		if photo, err = dropFolderPhotoForFile(filepath.Join(terminalDirectory, f.Name()), f, t); err != nil {
+			return
+		}
+		photos = append(photos, photo)
+	}
+
+	//Newest photos first like Graph API photos edge
+	sortSourcePhotosNewestFirst(photos)
+	return
+}
+
+//Read image file for photo.
+func (d dropFolderPhotoSource) PhotoImage(photo SourcePhoto) (imageData []byte, err error) {
+	imageData, err = ioutil.ReadFile(photo.Location)
+	return
+}
+
+//Create SourcePhoto for an image file in Terminal drop folder directory.
+func dropFolderPhotoForFile(path string, info os.FileInfo, t Terminal) (photo SourcePhoto, err error) {
+	photo.Location = path
+	photo.CreatedTime = info.ModTime()
+
+	//Use EXIF date taken if found
+	var imageData []byte
+	if imageData, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	var exifDate time.Time
+	var found bool
+	if exifDate, found, err = readExifDateTime(imageData, t.Timezone); err != nil {
+		log.Printf("Ignoring EXIF error for %v: %v\n", path, err)
+		err = nil
+	} else if found {
+		photo.CreatedTime = exifDate
+	}
+
+	photo.Id = dropFolderPhotoId(path, info)
+	return
+}
+
+//Return SourcePhoto.Id for an image file from filename and modification time so that a replaced file with the same name is treated as a new photo.
+func dropFolderPhotoId(path string, info os.FileInfo) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	base = strings.Replace(base, " ", "_", -1)
+	return fmt.Sprintf("%v_%v", base, info.ModTime().Unix())
+}
+
+//Check if filename has an image extension handled by saveImageForSlide
+func isDropFolderImageFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return true
+	}
+	return false
+}
+
+//Watch drop folder directory and process new image files as they appear.
+//Directory is scanned every DROP_FOLDER_POLL_INTERVAL. Files are processed once they have not been modified for DROP_FOLDER_SETTLE_TIME so partially copied files are not read.
+//Does not return.
+func watchDropFolder(d dropFolderPhotoSource, terminalArray []Terminal) {
+	//Terminals keyed by id to match terminal directories
+	terminalsById := make(map[string]Terminal)
+	for _, t := range terminalArray {
+		terminalsById[t.Id] = t
+	}
+
+	//Already processed files keyed by path. Value is modification time when processed.
+	//Files processed before a restart are found by their photo job in the photo jobs table.
+	processed := make(map[string]time.Time)
+
+	log.Printf("Watching drop folder %v\n", d.Directory)
+
+	for {
+		if err := scanDropFolder(d, terminalsById, processed); err != nil {
+			log.Println("Drop folder scan error: ", err)
+		}
+		time.Sleep(DROP_FOLDER_POLL_INTERVAL)
+	}
+}
+
+//Scan drop folder once and process new or modified image files.
+func scanDropFolder(d dropFolderPhotoSource, terminalsById map[string]Terminal, processed map[string]time.Time) (err error) {
+	var terminalDirectories []os.FileInfo
+	if terminalDirectories, err = ioutil.ReadDir(d.Directory); err != nil {
+		return
+	}
+
+	for _, terminalDirectory := range terminalDirectories {
+		if !terminalDirectory.IsDir() {
+			continue
+		}
+
+		t, ok := terminalsById[terminalDirectory.Name()]
+		if !ok {
+			log.Printf("Drop folder directory %v does not match a terminal id.\n", terminalDirectory.Name())
+			continue
+		}
+
+		var files []os.FileInfo
+		if files, err = ioutil.ReadDir(filepath.Join(d.Directory, terminalDirectory.Name())); err != nil {
+			return
+		}
+
+		for _, f := range files {
+			if f.IsDir() || !isDropFolderImageFile(f.Name()) {
+				continue
+			}
+
+			path := filepath.Join(d.Directory, terminalDirectory.Name(), f.Name())
+
+			//Skip files already processed at current modification time and files still being written
+			if processedModTime, ok := processed[path]; ok && processedModTime.Equal(f.ModTime()) {
+				continue
+			}
+			if time.Since(f.ModTime()) < DROP_FOLDER_SETTLE_TIME {
+				continue
+			}
+
+			//Skip files with a photo job from an earlier run
+			var jobFound bool
+			if jobFound, err = selectPhotoJobExistsForPhotoFromTable(PHOTO_JOBS_TABLE, t.Title, dropFolderPhotoId(path, f)); err != nil {
+				return
+			}
+			processed[path] = f.ModTime()
+			if jobFound {
+				continue
+			}
+
+			var photo SourcePhoto
+			if photo, err = dropFolderPhotoForFile(path, f, t); err != nil {
+				displayErrorForTerminal(t, err.Error())
+				err = nil
+				continue
+			}
+
+			//Ignore old photos
+			if !isPhotoRecent(photo) {
+				displayMessageForTerminal(t, path+" over 24 hours old.")
+				continue
+			}
+
+			displayMessageForTerminal(t, fmt.Sprintf("Drop folder processing %v", path))
+
+			//Create job to report photo processing status
+			var job *PhotoJob
+			if job, err = newPhotoJob(t, photo); err != nil {
+				return
+			}
+
+			var flightsFound int
+			if flightsFound, err = processPhotoNode(photo, d, t, job); err != nil {
+				displayErrorForTerminal(t, err.Error())
+				err = nil
+				continue
+			}
+			displayMessageForTerminal(t, fmt.Sprintf("Drop folder found %v flights in %v", flightsFound, path))
+		}
+	}
+	return
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+//Source of schedule photos for a Terminal.
+//Implementations list recently posted photos and fetch the image data for a photo so that processPhotoNode does not depend on where the photo came from.
+type PhotoSource interface {
+	//List recent photos for Terminal. Returned SourcePhoto must have Id and CreatedTime set.
+	RecentPhotos(t Terminal) (photos []SourcePhoto, err error)
+
+	//Fetch raw image bytes for a SourcePhoto returned by RecentPhotos.
+	PhotoImage(photo SourcePhoto) (imageData []byte, err error)
+}
+
+//Registered PhotoSource implementations keyed by name used in terminal file "photoSource" field.
+var photoSources = map[string]PhotoSource{
+	PHOTO_SOURCE_GRAPH: graphPhotoSource{},
+}
+
+//Register a PhotoSource under name so that terminals can select it.
+func registerPhotoSource(name string, source PhotoSource) {
+	photoSources[name] = source
+}
+
+//Return the PhotoSource selected for Terminal. Terminals without a photoSource use PHOTO_SOURCE_DEFAULT.
+func photoSourceForTerminal(t Terminal) (source PhotoSource, err error) {
+	name := t.PhotoSourceName
+	if len(name) == 0 {
+		name = PHOTO_SOURCE_DEFAULT
+	}
+
+	var ok bool
+	if source, ok = photoSources[name]; !ok {
+		err = fmt.Errorf("Unknown photo source %v for terminal %v.", name, t.Title)
+		return
+	}
+	return
+}
+
+//Sort photos by CreatedTime with newest photo first.
+func sortSourcePhotosNewestFirst(photos []SourcePhoto) {
+	sort.Slice(photos, func(i, j int) bool {
+		return photos[i].CreatedTime.After(photos[j].CreatedTime)
+	})
+}
+
+/*
+ * Facebook Graph API PhotoSource
+ */
+
+//PhotoSource reading the 72 hour album (or page photos) of the Terminal Facebook page through the Graph API.
+type graphPhotoSource struct{}
+
+//List photos in the Terminal 72 hour album. If no 72 hour album found, use Terminal page photos.
+func (graphPhotoSource) RecentPhotos(t Terminal) (photos []SourcePhoto, err error) {
+	//Request Albums edge from Graph API
+	//Try to find 72 hour album id. If no 72 hour album found, use terminal id.
+	var albumId string
+	if albumId, err = find72HrAlbumId(t); err != nil {
+		return
+	}
+	if len(albumId) == 0 {
+		albumId = t.Id
+		displayErrorForTerminal(t, "72 hour album not found.")
+	} else {
+		displayMessageForTerminal(t, "72 hour album found id "+albumId+".")
+		incrementTerminalsWith72HRAlbum()
+	}
+
+	//Request Photos edge from Graph API
+	var photosEdge PhotosEdge
+	if photosEdge, err = getPhotosEdge(albumId); err != nil {
+		return
+	}
+
+	for _, edgePhoto := range photosEdge.Data {
+		//http://stackoverflow.com/questions/24401901/time-parse-why-does-golang-parses-the-time-incorrectly
+		var photoUpdatedTime time.Time
+		if photoUpdatedTime, err = time.Parse(GRAPH_TIME_LAYOUT, edgePhoto.UpdatedTime); err != nil {
+			return
+		}
+
+		photos = append(photos, SourcePhoto{
+			Id:          edgePhoto.Id,
+			CreatedTime: photoUpdatedTime})
+	}
+	return
+}
+
+//Request Photo node for photo and download the first image.
+func (graphPhotoSource) PhotoImage(photo SourcePhoto) (imageData []byte, err error) {
+	var photoNode PhotoNode
+	if photoNode, err = getPhotoNode(photo.Id); err != nil {
+		return
+	}
+
+	imageData, err = downloadImageForPhotoNode(photoNode, photo.Id)
+	return
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Seat availability grammar
+ * Seat text is a count or range with optional F (firm), T (tentative) or SP (space permitting) code, two of those joined by "/", or TBD, SP or FULL.
+ * ex: 15F, 20-40, 15F/30T, 0, TBD, SP, FULL
+ */
+
+//Seat count or range with optional code. ex: 20-40f
+const seatsPartPattern = "\\d{1,3}(?:-\\d{1,3})?(?:f|t|sp)?"
+
+//Seat text in lowercase plain text
+var seatsTextRegex = regexp.MustCompile("\\b(full|tbd|sp|" + seatsPartPattern + "(?:/" + seatsPartPattern + ")?)\\b")
+
+//Captures count, range end and code of seat text part
+var seatsPartRegex = regexp.MustCompile("^(\\d{1,3})(?:-(\\d{1,3}))?(f|t|sp)?$")
+
+//Return SEAT_CODE_XXX for seat letter code
+func seatCodeForLetter(letter string) string {
+	switch letter {
+	case "f":
+		return SEAT_CODE_FIRM
+	case "t":
+		return SEAT_CODE_TENTATIVE
+	case "sp":
+		return SEAT_CODE_SPACE_PERMITTING
+	case "tbd":
+		return SEAT_CODE_TBD
+	}
+	return SEAT_CODE_UNSPECIFIED
+}
+
+//Parse seat text into SeatsAvailable without BBox. ok is false if text does not follow seat grammar.
+func parseSeatsText(text string) (sa SeatsAvailable, ok bool) {
+	text = strings.ToLower(text)
+	sa.Spelling = text
+
+	switch text {
+	case "full":
+		sa.Code = SEAT_CODE_FULL
+		return sa, true
+	case "tbd", "sp":
+		sa.Letter = text
+		sa.Code = seatCodeForLetter(text)
+		return sa, true
+	}
+
+	codes := make(map[string]bool)
+	for partIndex, part := range strings.Split(text, "/") {
+		match := seatsPartRegex.FindStringSubmatch(part)
+		if match == nil {
+			return SeatsAvailable{}, false
+		}
+
+		min, _ := strconv.Atoi(match[1])
+		max := min
+		if len(match[2]) > 0 {
+			max, _ = strconv.Atoi(match[2])
+		}
+		if max < min {
+			min, max = max, min
+		}
+
+		code := seatCodeForLetter(match[3])
+		codes[code] = true
+		if code == SEAT_CODE_FIRM {
+			sa.Firm += min
+		}
+		if partIndex == 0 {
+			sa.Letter = match[3]
+		}
+		sa.Min += min
+		sa.Max += max
+	}
+
+	sa.Number = sa.Max
+
+	//Single code, firm and tentative mix, or unspecified mix of codes
+	switch {
+	case len(codes) == 1:
+		for code := range codes {
+			sa.Code = code
+		}
+	case len(codes) == 2 && codes[SEAT_CODE_FIRM] && codes[SEAT_CODE_TENTATIVE]:
+		sa.Code = SEAT_CODE_FIRM_TENTATIVE
+		sa.Min = sa.Firm
+		//Legacy seat count and type are the firm seats. ex: 15F/30T is 15 F
+		sa.Letter = "f"
+		sa.Number = sa.Firm
+	default:
+		sa.Code = SEAT_CODE_UNSPECIFIED
+	}
+
+	//ex: 0, 0F
+	if sa.Max == 0 {
+		sa.Code = SEAT_CODE_FULL
+	}
+
+	return sa, true
+}
+
+//Return true if sa was parsed from more seat text than other, or from the same length of text with more seats. ex: 15F/30T over 15F
+func (sa SeatsAvailable) isMoreSpecificThan(other SeatsAvailable) bool {
+	if len(sa.Spelling) != len(other.Spelling) {
+		return len(sa.Spelling) > len(other.Spelling)
+	}
+	return sa.Max > other.Max
+}
+
+//Return flights with at least minFirmSeats firm seats.
+func filterFlightsByFirmSeats(flights []Flight, minFirmSeats int) (filtered []Flight) {
+	for _, f := range flights {
+		if f.FirmSeatCount >= minFirmSeats {
+			filtered = append(filtered, f)
+		}
+	}
+	return
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"crypto/subtle"
 	"crypto/tls"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
@@ -253,13 +255,51 @@ func flightsHandler(w http.ResponseWriter, r *http.Request) {
 		origin,
 		destination,
 		startTime,
-		duration); err != nil {
+		duration); err == nil {
+		//Add legs of multi stop flights
+		err = selectFlightLegsOfFlightsFromTable(FLIGHT_LEGS_TABLE, foundFlights)
+	}
+	if err != nil {
 		fmt.Fprintf(w, SAResponse{
 			Status: 2,
 			Error:  fmt.Sprintf("Query error: %v", err.Error())}.createJSONOutput())
 		return
 	}
 
+	//Only return flights with REST_STATUS_KEY statuses if set
+	if statusText := r.Form.Get(REST_STATUS_KEY); len(statusText) > 0 {
+		foundFlights = filterFlightsByStatus(foundFlights, strings.Split(statusText, ","))
+	}
+
+	//Only return flights with REST_AIRCRAFT_KEY aircraft if set
+	if aircraftText := r.Form.Get(REST_AIRCRAFT_KEY); len(aircraftText) > 0 {
+		foundFlights = filterFlightsByAircraft(foundFlights, strings.Split(aircraftText, ","))
+	}
+
+	//Only return flights with at least REST_FIRM_SEATS_KEY firm seats if set
+	if firmSeatsText := r.Form.Get(REST_FIRM_SEATS_KEY); len(firmSeatsText) > 0 {
+		var minFirmSeats int
+		if minFirmSeats, err = strconv.Atoi(firmSeatsText); err != nil {
+			fmt.Fprint(w, SAResponse{
+				Status: 1,
+				Error:  fmt.Sprintf("%v parameter error: %v", REST_FIRM_SEATS_KEY, err.Error())}.createJSONOutput())
+			return
+		}
+		foundFlights = filterFlightsByFirmSeats(foundFlights, minFirmSeats)
+	}
+
+	//Hide flights below REST_MIN_CONFIDENCE_KEY confidence if set
+	if minConfidenceText := r.Form.Get(REST_MIN_CONFIDENCE_KEY); len(minConfidenceText) > 0 {
+		var minConfidence float64
+		if minConfidence, err = strconv.ParseFloat(minConfidenceText, 64); err != nil {
+			fmt.Fprint(w, SAResponse{
+				Status: 1,
+				Error:  fmt.Sprintf("%v parameter error: %v", REST_MIN_CONFIDENCE_KEY, err.Error())}.createJSONOutput())
+			return
+		}
+		foundFlights = filterFlightsByConfidence(foundFlights, minConfidence)
+	}
+
 	fmt.Fprintf(w, SAResponse{
 		Status:  0,
 		Flights: foundFlights}.createJSONOutput())
@@ -312,6 +352,172 @@ func submitPhotoReportHandler(w http.ResponseWriter, r *http.Request) {
 		Status:  0}.createJSONOutput())
 }
 
+//Check request Authorization header "Bearer <token>" against PHOTO_UPLOAD_TOKEN_ENV environment variable.
+func uploadRequestAuthorized(r *http.Request) (authorized bool, err error) {
+	uploadToken := os.Getenv(PHOTO_UPLOAD_TOKEN_ENV)
+	if len(uploadToken) == 0 {
+		err = fmt.Errorf("Photo upload disabled. %v not set.", PHOTO_UPLOAD_TOKEN_ENV)
+		return
+	}
+
+	requestToken := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	authorized = subtle.ConstantTimeCompare([]byte(requestToken), []byte(uploadToken)) == 1
+	return
+}
+
+//Accept multipart schedule photo upload for a terminal and queue it for OCR. Return job id.
+func uploadPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	var err error
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Method %v not allowed.", r.Method)}.createJSONOutput())
+		return
+	}
+
+	//Check upload token
+	var authorized bool
+	if authorized, err = uploadRequestAuthorized(r); err != nil || !authorized {
+		if err == nil {
+			err = fmt.Errorf("Invalid upload token.")
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Unauthorized: %v", err.Error())}.createJSONOutput())
+		return
+	}
+
+	//Parse multipart form
+	r.Body = http.MaxBytesReader(w, r.Body, PHOTO_UPLOAD_MAX_BYTES)
+	if err = r.ParseMultipartForm(PHOTO_UPLOAD_MAX_BYTES); err != nil {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Parse form error: %v", err.Error())}.createJSONOutput())
+		return
+	}
+
+	//Find terminal for title
+	var terminalTitle string
+	if terminalTitle = r.Form.Get(REST_TERMINAL_KEY); len(terminalTitle) == 0 {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Missing %v parameter.", REST_TERMINAL_KEY)}.createJSONOutput())
+		return
+	}
+
+	var terminalArray []Terminal
+	if terminalArray, err = readTerminalArrayFromFiles(TERMINAL_FILE); err != nil {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Get terminals error: %v", err.Error())}.createJSONOutput())
+		return
+	}
+	terminal, ok := readTerminalArrayToMap(terminalArray)[terminalTitle]
+	if !ok {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("%v parameter error: unknown terminal %v", REST_TERMINAL_KEY, terminalTitle)}.createJSONOutput())
+		return
+	}
+
+	//Read uploaded image
+	photoFile, _, err := r.FormFile(REST_PHOTO_KEY)
+	if err != nil {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("%v parameter error: %v", REST_PHOTO_KEY, err.Error())}.createJSONOutput())
+		return
+	}
+	defer photoFile.Close()
+
+	var imageData []byte
+	if imageData, err = ioutil.ReadAll(photoFile); err != nil {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Read photo error: %v", err.Error())}.createJSONOutput())
+		return
+	}
+
+	if contentType := http.DetectContentType(imageData); !strings.HasPrefix(contentType, "image/") {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("%v parameter error: %v is not an image", REST_PHOTO_KEY, contentType)}.createJSONOutput())
+		return
+	}
+
+	//Queue job with image for OCR by worker process
+	var photoId string
+	if photoId, err = newPhotoJobId(); err != nil {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Create photo id error: %v", err.Error())}.createJSONOutput())
+		return
+	}
+
+	var job PhotoJob
+	if job, err = newUploadPhotoJob(terminal, SourcePhoto{
+		Id:          PHOTO_UPLOAD_ID_PREFIX + photoId,
+		CreatedTime: time.Now()}, imageData); err != nil {
+		if err == errPhotoJobQueueFull {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprint(w, SAResponse{
+			Status: 2,
+			Error:  fmt.Sprintf("Queue photo error: %v", err.Error())}.createJSONOutput())
+		return
+	}
+
+	log.Printf("Photo job %v queued for %v\n", job.Id, terminal.Title)
+
+	fmt.Fprint(w, SAResponse{
+		Status: 0,
+		JobId:  job.Id}.createJSONOutput())
+}
+
+//Return processing state, slide date, flights and error of photo job. GET /jobs/{id}
+func jobStatusHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	var err error
+
+	var jobId string
+	if jobId = strings.TrimPrefix(r.URL.Path, REST_JOBS_PATH); len(jobId) == 0 {
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  "Missing job id."}.createJSONOutput())
+		return
+	}
+
+	var job PhotoJob
+	var found bool
+	if job, found, err = findPhotoJob(jobId); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, SAResponse{
+			Status: 2,
+			Error:  fmt.Sprintf("Query error: %v", err.Error()),
+			JobId:  jobId}.createJSONOutput())
+		return
+	}
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, SAResponse{
+			Status: 1,
+			Error:  fmt.Sprintf("Job %v not found.", jobId),
+			JobId:  jobId}.createJSONOutput())
+		return
+	}
+
+	fmt.Fprint(w, SAResponse{
+		Status: 0,
+		JobId:  job.Id,
+		Job:    &job}.createJSONOutput())
+}
+
 func runServer(wg *sync.WaitGroup, config *tls.Config) {
 
 	serverStartTime = time.Now()
@@ -339,6 +545,12 @@ func runServer(wg *sync.WaitGroup, config *tls.Config) {
 	//Log photo report from user
 	http.HandleFunc("/submitPhotoReport", submitPhotoReportHandler)
 
+	//Upload schedule photo for processing
+	http.HandleFunc("/photos", uploadPhotoHandler)
+
+	//Get photo processing job status
+	http.HandleFunc(REST_JOBS_PATH, jobStatusHandler)
+
 	err := http.ListenAndServe(":"+os.Getenv("PORT"), nil)
 	if err != nil {
 		panic(err)
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"image"
+	"sort"
+)
+
+/*
+ * Table layout analysis
+ * Detects ruling lines, row color band edges and whitespace gutters of the schedule table to build a row/column grid.
+ */
+
+//Row and column of a TableGrid cell. -1 if outside grid.
+type GridCell struct {
+	Row    int
+	Column int
+}
+
+//Row and column grid of the schedule table in slide image coordinates
+type TableGrid struct {
+	Bounds  image.Rectangle //Table area below header label
+	Rows    []int           //Y coordinates of row boundaries in increasing order. Row i is between Rows[i] and Rows[i+1].
+	Columns []int           //X coordinates of column boundaries in increasing order. Column i is between Columns[i] and Columns[i+1].
+}
+
+//Return true if grid has enough rows to link text by row instead of vertical distance
+func (g TableGrid) hasRows() bool {
+	return len(g.Rows)-1 >= GRID_MIN_ROWS
+}
+
+//Return true if grid has more than one column
+func (g TableGrid) hasColumns() bool {
+	return len(g.Columns)-1 > 1
+}
+
+//Return index of interval in boundaries containing value. -1 if outside.
+func gridIntervalIndex(boundaries []int, value int) int {
+	for i := 0; i+1 < len(boundaries); i++ {
+		if value >= boundaries[i] && value < boundaries[i+1] {
+			return i
+		}
+	}
+	return -1
+}
+
+//Return cell containing center of bbox
+func (g TableGrid) cellForRect(bbox image.Rectangle) (cell GridCell) {
+	center := image.Pt((bbox.Min.X+bbox.Max.X)/2, (bbox.Min.Y+bbox.Max.Y)/2)
+	cell.Row = gridIntervalIndex(g.Rows, center.Y)
+	cell.Column = gridIntervalIndex(g.Columns, center.X)
+	return
+}
+
+//Set Cell of every word
+func (g TableGrid) assignWordCells(words []OCRWord) {
+	for i := range words {
+		words[i].Cell = g.cellForRect(words[i].BBox)
+	}
+}
+
+//Detect table grid for slides. Rulings are detected in the original slide image and gutters from OCR words of all slides.
+//headerBBox is the bbox of the Destination label. Table area starts at the top of the header.
+//lineHeight is text line height in pixels used to scale edge detection and merge nearby edges.
+func detectTableGridOfPhotoNodeSlides(slides []Slide, headerBBox image.Rectangle, lineHeight int) (grid TableGrid, err error) {
+	originalSlide := slides[0]
+	originalSlide.SaveType = SAVE_IMAGE_TRAINING
+	originalSlide.Suffix = ""
+
+	var img image.Image
+	if img, err = readImageFile(photoPath(originalSlide)); err != nil {
+		return
+	}
+	gray := grayscaleImage(img)
+	width, height := gray.Bounds().Dx(), gray.Bounds().Dy()
+
+	grid.Bounds = image.Rect(0, headerBBox.Min.Y, width, height)
+	edgeDistance := lineHeightsToPixels(lineHeight, GRID_EDGE_DISTANCE)
+	if edgeDistance < 1 {
+		edgeDistance = 1
+	}
+	maxGap := lineHeightsToPixels(lineHeight, GRID_RULING_MAX_GAP)
+	mergeDistance := lineHeightsToPixels(lineHeight, GRID_RULING_MERGE_DISTANCE)
+
+	//Horizontal rulings below top of header are row boundaries
+	for _, y := range detectHorizontalRulings(gray, edgeDistance, maxGap, mergeDistance) {
+		if y >= headerBBox.Min.Y {
+			grid.Rows = append(grid.Rows, y)
+		}
+	}
+	if len(grid.Rows) == 0 || grid.Rows[0] > headerBBox.Min.Y {
+		grid.Rows = append([]int{headerBBox.Min.Y}, grid.Rows...)
+	}
+	if grid.Rows[len(grid.Rows)-1] < height {
+		grid.Rows = append(grid.Rows, height)
+	}
+
+	//Words in table area of all slides
+	var tableWords []OCRWord
+	for _, s := range slides {
+		for _, w := range s.Words {
+			if w.BBox.Min.Y >= headerBBox.Min.Y {
+				tableWords = append(tableWords, w)
+			}
+		}
+	}
+
+	//Column boundaries from vertical rulings. Use whitespace gutters between words if table has no vertical rulings.
+	columns := detectVerticalRulings(gray, grid.Bounds, edgeDistance, maxGap, mergeDistance)
+	if len(columns) == 0 {
+		columns = detectWhitespaceGutters(tableWords, width)
+	}
+	columns = append(columns, 0, width)
+	grid.Columns = mergeBoundaries(columns, mergeDistance)
+
+	for i := range slides {
+		grid.assignWordCells(slides[i].Words)
+	}
+	return
+}
+
+//Find Y coordinates of horizontal ruling lines and row color band edges.
+//A boundary is a row of pixels with a run of strong contrast to the row edgeDistance pixels above covering GRID_RULING_MIN_LENGTH_HORIZONTAL of image width. Runs may have gaps up to maxGap pixels.
+//Edges closer than mergeDistance pixels are merged.
+func detectHorizontalRulings(gray *image.Gray, edgeDistance int, maxGap int, mergeDistance int) (boundaries []int) {
+	width, height := gray.Bounds().Dx(), gray.Bounds().Dy()
+	minRun := int(GRID_RULING_MIN_LENGTH_HORIZONTAL * float64(width))
+
+	var candidates []int
+	for y := edgeDistance; y < height; y++ {
+		if longestContrastRun(width, maxGap, func(x int) bool {
+			return absInt(int(gray.Pix[y*gray.Stride+x])-int(gray.Pix[(y-edgeDistance)*gray.Stride+x])) >= GRID_EDGE_CONTRAST
+		}) >= minRun {
+			candidates = append(candidates, y)
+		}
+	}
+
+	boundaries = mergeBoundaries(candidates, mergeDistance)
+	return
+}
+
+//Find X coordinates of vertical ruling lines and column color band edges within area.
+func detectVerticalRulings(gray *image.Gray, area image.Rectangle, edgeDistance int, maxGap int, mergeDistance int) (boundaries []int) {
+	area = area.Intersect(gray.Bounds())
+	minRun := int(GRID_RULING_MIN_LENGTH_VERTICAL * float64(area.Dy()))
+
+	var candidates []int
+	for x := area.Min.X + edgeDistance; x < area.Max.X; x++ {
+		if longestContrastRun(area.Dy(), maxGap, func(i int) bool {
+			y := area.Min.Y + i
+			return absInt(int(gray.Pix[y*gray.Stride+x])-int(gray.Pix[y*gray.Stride+x-edgeDistance])) >= GRID_EDGE_CONTRAST
+		}) >= minRun {
+			candidates = append(candidates, x)
+		}
+	}
+
+	boundaries = mergeBoundaries(candidates, mergeDistance)
+	return
+}
+
+//Return longest run of positions 0..length-1 where isEdge is true allowing gaps up to maxGap.
+func longestContrastRun(length int, maxGap int, isEdge func(i int) bool) (longest int) {
+	runStart, lastEdge := -1, -1
+	for i := 0; i < length; i++ {
+		if !isEdge(i) {
+			continue
+		}
+		if runStart == -1 || i-lastEdge > maxGap+1 {
+			runStart = i
+		}
+		lastEdge = i
+		if run := lastEdge - runStart + 1; run > longest {
+			longest = run
+		}
+	}
+	return
+}
+
+//Find X coordinates of centers of vertical whitespace gaps between words at least GRID_MIN_GUTTER_WIDTH of image width wide.
+func detectWhitespaceGutters(words []OCRWord, width int) (gutters []int) {
+	if len(words) == 0 {
+		return
+	}
+
+	covered := make([]bool, width)
+	for _, w := range words {
+		for x := clampInt(w.BBox.Min.X, 0, width); x < clampInt(w.BBox.Max.X, 0, width); x++ {
+			covered[x] = true
+		}
+	}
+
+	//Only gaps between words count. Ignore margins left and right of all words.
+	minX, maxX := width, 0
+	for x, c := range covered {
+		if c {
+			if x < minX {
+				minX = x
+			}
+			maxX = x
+		}
+	}
+
+	minGutter := int(GRID_MIN_GUTTER_WIDTH * float64(width))
+	gapStart := -1
+	for x := minX; x <= maxX; x++ {
+		if !covered[x] {
+			if gapStart == -1 {
+				gapStart = x
+			}
+			continue
+		}
+		if gapStart != -1 && x-gapStart >= minGutter {
+			gutters = append(gutters, (gapStart+x)/2)
+		}
+		gapStart = -1
+	}
+	return
+}
+
+//Sort boundaries and merge boundaries closer than distance into their average
+func mergeBoundaries(boundaries []int, distance int) (merged []int) {
+	sort.Ints(boundaries)
+
+	var cluster []int
+	flush := func() {
+		if len(cluster) == 0 {
+			return
+		}
+		sum := 0
+		for _, b := range cluster {
+			sum += b
+		}
+		merged = append(merged, sum/len(cluster))
+		cluster = nil
+	}
+
+	for _, b := range boundaries {
+		if len(cluster) > 0 && b-cluster[len(cluster)-1] > distance {
+			flush()
+		}
+		cluster = append(cluster, b)
+	}
+	flush()
+	return
+}
+
+//Absolute value of int
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+/*
+ * Grid based linking
+ */
+
+//Remove Destinations that are not in grid column. No-op if column is -1 or grid has no columns.
+func deleteDestsOutsideColumnFromDestArray(arrayPointer *[]Destination, grid TableGrid, column int) {
+	if !grid.hasColumns() || column == -1 {
+		return
+	}
+
+	var kept []Destination
+	for _, d := range *arrayPointer {
+		if grid.cellForRect(d.BBox).Column == column {
+			kept = append(kept, d)
+		}
+	}
+	*arrayPointer = kept
+}
+
+//Remove RollCalls that are not in grid column. No-op if column is -1 or grid has no columns.
+func deleteRCsOutsideColumnFromRCArray(arrayPointer *[]RollCall, grid TableGrid, column int) {
+	if !grid.hasColumns() || column == -1 {
+		return
+	}
+
+	var kept []RollCall
+	for _, rc := range *arrayPointer {
+		if grid.cellForRect(rc.BBox).Column == column {
+			kept = append(kept, rc)
+		}
+	}
+	*arrayPointer = kept
+}
+
+//Return one OCRWord per OCR line in document order. Text is the line words separated by spaces and BBox is the union of word boxes.
+func ocrWordLines(words []OCRWord) (lines []OCRWord) {
+	for _, w := range words {
+		if len(lines) > 0 && lines[len(lines)-1].LineId == w.LineId {
+			line := &lines[len(lines)-1]
+			line.Text += " " + w.Text
+			line.BBox = line.BBox.Union(w.BBox)
+			if w.Confidence < line.Confidence {
+				line.Confidence = w.Confidence
+			}
+			continue
+		}
+		lines = append(lines, w)
+	}
+	return
+}
+
+//Return copies of slides with only the OCR words inside region. PlainText is rebuilt from the kept words one OCR line per text line.
+func restrictSlidesToRegion(slides []Slide, region image.Rectangle) (restricted []Slide) {
+	for _, s := range slides {
+		var words []OCRWord
+		var plainText string
+		for _, w := range s.Words {
+			center := image.Pt((w.BBox.Min.X+w.BBox.Max.X)/2, (w.BBox.Min.Y+w.BBox.Max.Y)/2)
+			if !center.In(region) {
+				continue
+			}
+
+			if len(words) > 0 {
+				if words[len(words)-1].LineId == w.LineId {
+					plainText += " "
+				} else {
+					plainText += "\n"
+				}
+			}
+			plainText += w.Text
+			words = append(words, w)
+		}
+
+		s.Words = words
+		s.PlainText = plainText
+		restricted = append(restricted, s)
+	}
+	return
+}
+
+//Return true if bbox is in the table row of Destination.
+//Rows are grid rows if grid has rows, otherwise text vertically overlapping the Destination or its linked RollCall.
+func isInDestinationRow(grid TableGrid, d Destination, bbox image.Rectangle, lineHeight int) bool {
+	if grid.hasRows() {
+		row := grid.cellForRect(bbox).Row
+		return row != -1 && row == grid.cellForRect(d.BBox).Row
+	}
+
+	linkThreshold := lineHeightsToPixels(lineHeight, ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD)
+	if getVerticalDistance(d.BBox, bbox) < linkThreshold {
+		return true
+	}
+	return d.LinkedRollCall != nil && getVerticalDistance((*d.LinkedRollCall).BBox, bbox) < linkThreshold
+}
+
+//Link RollCalls, Destinations and SeatsAvailable that are in the same grid row.
+//Every Destination in a row is linked to the row RollCall so multiple destination lines in one row form a single Grouping.
+func linkByGridRows(grid TableGrid, rcs []RollCall, dests []Destination, saArray []SeatsAvailable) {
+	rollCallForRow := make(map[int]*RollCall)
+	for rcIndex := range rcs {
+		row := grid.cellForRect(rcs[rcIndex].BBox).Row
+		if row == -1 {
+			continue
+		}
+		//Keep first RollCall found in row
+		if _, ok := rollCallForRow[row]; !ok {
+			rollCallForRow[row] = &rcs[rcIndex]
+		}
+	}
+
+	seatsForRow := make(map[int]*SeatsAvailable)
+	for saIndex := range saArray {
+		row := grid.cellForRect(saArray[saIndex].BBox).Row
+		if row == -1 {
+			continue
+		}
+		if _, ok := seatsForRow[row]; !ok {
+			seatsForRow[row] = &saArray[saIndex]
+		}
+	}
+
+	for row, rc := range rollCallForRow {
+		if sa, ok := seatsForRow[row]; ok {
+			rc.LinkedSeatsAvailable = sa
+		}
+	}
+
+	for dIndex := range dests {
+		row := grid.cellForRect(dests[dIndex].BBox).Row
+		if row == -1 {
+			continue
+		}
+		if rc, ok := rollCallForRow[row]; ok {
+			dests[dIndex].LinkedRollCall = rc
+		}
+		if sa, ok := seatsForRow[row]; ok {
+			dests[dIndex].LinkedSeatsAvailable = sa
+		}
+	}
+}
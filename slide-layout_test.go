@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//Write fixture image and OCR fixtures enlarged by scale into a temporary directory. Call returned cleanup function to remove.
+func writeScaledFixtures(t *testing.T, scale int) (imagePath string, fixtureDirectory string, cleanup func()) {
+	directory, err := ioutil.TempDir("", "spacea_scaled_fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup = func() { os.RemoveAll(directory) }
+
+	//Nearest neighbor enlarged image
+	var img image.Image
+	if img, err = readImageFile(fixtureImage); err != nil {
+		t.Fatal(err)
+	}
+	bounds := img.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, bounds.Dx()*scale, bounds.Dy()*scale))
+	for y := 0; y < scaled.Bounds().Dy(); y++ {
+		for x := 0; x < scaled.Bounds().Dx(); x++ {
+			scaled.Set(x, y, img.At(bounds.Min.X+x/scale, bounds.Min.Y+y/scale))
+		}
+	}
+	imagePath = filepath.Join(directory, filepath.Base(fixtureImage))
+	if err = writeImageFile(scaled, imagePath); err != nil {
+		t.Fatal(err)
+	}
+
+	//OCR fixtures with hOCR bboxes multiplied by scale
+	bboxRegex := regexp.MustCompile("bbox ([0-9]+) ([0-9]+) ([0-9]+) ([0-9]+)")
+	fixtureDirectory = filepath.Join(directory, "ocr_fixtures")
+	err = filepath.Walk(fixtureOCRDirectory, func(path string, info os.FileInfo, walkErr error) (err error) {
+		if walkErr != nil || info.IsDir() {
+			return walkErr
+		}
+
+		var fixture []byte
+		if fixture, err = ioutil.ReadFile(path); err != nil {
+			return
+		}
+		if strings.HasSuffix(path, "."+TESS_OUTPUT_HOCR_EXTENSION) {
+			fixture = bboxRegex.ReplaceAllFunc(fixture, func(bbox []byte) []byte {
+				fields := strings.Fields(string(bbox))
+				for i := 1; i < len(fields); i++ {
+					coordinate, _ := strconv.Atoi(fields[i])
+					fields[i] = strconv.Itoa(coordinate * scale)
+				}
+				return []byte(strings.Join(fields, " "))
+			})
+		}
+
+		var relativePath string
+		if relativePath, err = filepath.Rel(fixtureOCRDirectory, path); err != nil {
+			return
+		}
+		scaledPath := filepath.Join(fixtureDirectory, relativePath)
+		if err = os.MkdirAll(filepath.Dir(scaledPath), os.ModePerm); err != nil {
+			return
+		}
+		return ioutil.WriteFile(scaledPath, fixture, 0644)
+	})
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return
+}
+
+//Return grid rows and sorted flight summaries without bboxes for fixture schedule enlarged by scale.
+func parseScaledFixtureSchedule(t *testing.T, scale int) (gridRows int, summaries []string) {
+	imagePath, fixtureDirectory, cleanup := writeScaledFixtures(t, scale)
+	defer cleanup()
+
+	withFixtureScheduleSlide(t, imagePath, fixtureDirectory, func(slide Slide) {
+		slides := []Slide{slide}
+
+		lineHeight, err := measureTextLineHeight(slides)
+		if err != nil {
+			t.Fatal(err)
+		}
+		destLabelBBox, err := findLabelBoundsOfPhotoNodeSlides(slides, KEYWORD_DESTINATION)
+		if err != nil {
+			t.Fatal(err)
+		}
+		grid, err := detectTableGridOfPhotoNodeSlides(slides, destLabelBBox, lineHeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gridRows = len(grid.Rows) - 1
+
+		var flights []Flight
+		if flights, _, _, err = parseFlightsFromSlides(slides, nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range flights {
+			summaries = append(summaries, fmt.Sprintf("%v %v %v %v-%v firm %v %v %.3f", f.Destination, f.RollCall.Format("1504"), f.SeatCode, f.SeatMin, f.SeatMax, f.FirmSeatCount, f.Status, f.Confidence))
+		}
+	})
+	sort.Strings(summaries)
+	return
+}
+
+//Same schedule photographed at 3x resolution should have the same table rows and flights.
+func TestDetectTableGridScaled(t *testing.T) {
+	rows1x, flights1x := parseScaledFixtureSchedule(t, 1)
+	rows3x, flights3x := parseScaledFixtureSchedule(t, 3)
+
+	if rows1x < GRID_MIN_ROWS {
+		t.Errorf("Found %v table rows at 1x, expected at least %v", rows1x, GRID_MIN_ROWS)
+	}
+	if rows3x != rows1x {
+		t.Errorf("Found %v table rows at 3x, expected %v as at 1x", rows3x, rows1x)
+	}
+	if len(flights1x) == 0 {
+		t.Fatal("No flights found at 1x")
+	}
+	if strings.Join(flights3x, "\n") != strings.Join(flights1x, "\n") {
+		t.Errorf("Flights at 3x\n%v\ndiffer from flights at 1x\n%v", strings.Join(flights3x, "\n"), strings.Join(flights1x, "\n"))
+	}
+}
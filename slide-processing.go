@@ -11,12 +11,16 @@ import (
 	"strconv"
 	"strings"
 	"time"
-	//"unicode"
+	"unicode"
 )
 
 //Find date of 72 hour slide in header by looking for month name
 //Returned time.Time is set to TZ of slides[0]
-func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error) {
+func findDateOfPhotoNodeSlides(slides []Slide, headerRegion image.Rectangle) (slideDate time.Time, err error) {
+	//Only search header region text if known
+	if !headerRegion.Empty() {
+		slides = restrictSlidesToRegion(slides, headerRegion)
+	}
 
 	//Build months name array
 	var monthsLong []string  //Long month ex:January
@@ -36,13 +40,19 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 		nextMonth = time.January
 	}
 
+	//Text line height to scale date crop buffer
+	var lineHeight int
+	if lineHeight, err = measureTextLineHeight(slides); err != nil {
+		return
+	}
+
 	//Search for the current and next month strings
 	var closestMonthSpelling string
 	var closestMonthSlide Slide
-	monthsSearchArray := []string{monthsLong[currentMonth-1], monthsLong[nextMonth-1], monthsShort[currentMonth-1], monthsLong[nextMonth-1]}
+	monthsSearchArray := []string{monthsLong[currentMonth-1], monthsLong[nextMonth-1], monthsShort[currentMonth-1], monthsShort[nextMonth-1]}
 
-	//Look through all slides (savetypes) to find closest date to time.Now. Assume any OCR error dates will be at higher date difference than a correct OCR date within ~72 hours.
-	compareTargetDate := time.Now()
+	//Look through all slides (savetypes) to find closest date to photo creation. Assume any OCR error dates will be at higher date difference than a correct OCR date within ~72 hours.
+	compareTargetDate := referenceTimeOfSlides(slides)
 	for i, v := range monthsSearchArray {
 		if closestMonthSpelling, closestMonthSlide, err = findKeywordClosestSpellingInPhotoInSaveImageTypes(v, slides); err != nil {
 			return
@@ -59,12 +69,8 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 		if len(closestMonthSpelling) > 0 {
 			//We found a close spelling, move onto finding bounding box
 
-			//Find month bounds in hOCR
-			var bboxes []image.Rectangle
-			bboxes, err = getTextBounds(closestMonthSlide.HOCRText, closestMonthSpelling)
-			if err != nil {
-				return
-			}
+			//Find month bounds in OCR words
+			bboxes := findWordBounds(closestMonthSlide.Words, closestMonthSpelling)
 
 			if len(bboxes) == 0 {
 				err = fmt.Errorf("No bboxes found for month??.")
@@ -76,7 +82,7 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 			for _, s := range slides {
 				var foundDate time.Time
 				//Try to find date from uncropped image
-				if foundDate, err = findDateFromPlainText(s.PlainText, closestMonthSpelling, estimatedMonth, s.Terminal.Timezone); err != nil {
+				if foundDate, err = findDateFromPlainText(s.PlainText, closestMonthSpelling, estimatedMonth, s.FBCreatedTime, s.Terminal.Timezone); err != nil {
 					return
 				}
 
@@ -93,18 +99,18 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 
 				//Try to find date on cropped image
 				//Crop current slide to only show the image line that month was found on
-				if err = runImageMagickDateCropVerticalProcess(s, bbox.Min.Y-5, (bbox.Max.Y-bbox.Min.Y)+10); err != nil {
+				cropBuffer := lineHeightsToPixels(lineHeight, DATE_CROP_VERTICAL_BUFFER)
+				var copySlide Slide
+				if copySlide, err = runImageDateCropVerticalProcess(s, bbox.Min.Y-cropBuffer, (bbox.Max.Y-bbox.Min.Y)+2*cropBuffer); err != nil {
 					return
 				}
 				//Run OCR on cropped image for current slide using bbox
-				copySlide := s
-				copySlide.Suffix = IMAGE_SUFFIX_CROPPED
 				if err = doOCRForSlide(&copySlide, OCR_WHITELIST_NORMAL); err != nil {
 					return
 				}
 
 				//Try to find date from cropped image
-				if foundDate, err = findDateFromPlainText(copySlide.PlainText, closestMonthSpelling, estimatedMonth, s.Terminal.Timezone); err != nil {
+				if foundDate, err = findDateFromPlainText(copySlide.PlainText, closestMonthSpelling, estimatedMonth, s.FBCreatedTime, s.Terminal.Timezone); err != nil {
 					return
 				}
 
@@ -122,7 +128,7 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 		for _, s := range slides {
 			var foundDate time.Time
 			//Try to find date from uncropped image
-			if foundDate, err = findDateFromPlainText(s.PlainText, v, estimatedMonth, s.Terminal.Timezone); err != nil {
+			if foundDate, err = findDateFromPlainText(s.PlainText, v, estimatedMonth, s.FBCreatedTime, s.Terminal.Timezone); err != nil {
 				return
 			}
 
@@ -139,8 +145,8 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 		}
 	}
 
-	//If found date is > 144hr away from current time, assume we got the wrong date since we are only looking at recent photos
-	if math.Abs(float64(time.Since(slideDate))) > float64(time.Hour*144) {
+	//If found date is > 144hr away from photo creation, assume we got the wrong date since 72 hour slides only show nearby days
+	if math.Abs(float64(compareTargetDate.Sub(slideDate))) > float64(time.Hour*144) {
 		slideDate = time.Time{}
 	}
 
@@ -150,47 +156,41 @@ func findDateOfPhotoNodeSlides(slides []Slide) (slideDate time.Time, err error)
 }
 
 //Return time.Time of detected date for slide.
-func findDateFromPlainText(plainText string, closestMonthSpelling string, estimatedMonth time.Month, slideTZ *time.Location) (date time.Time, err error) {
+//Matches day and month name in either order with optional weekday, ordinal suffix and 2 or 4 digit year (27MAR18, TUESDAY 27 MARCH, March 27th 2018).
+//Falls back to numeric dates (03/27/2018, 2018-03-27). Year-less dates use the year closest to reference.
+func findDateFromPlainText(plainText string, closestMonthSpelling string, estimatedMonth time.Month, reference time.Time, slideTZ *time.Location) (date time.Time, err error) {
+	if reference.IsZero() {
+		reference = time.Now()
+	}
+
 	//Lowercase closestMonthSpelling
 	closestMonthSpelling = strings.ToLower(closestMonthSpelling)
 
 	//fmt.Println("find date with closestMonthSpelling ", closestMonthSpelling)
 	//fmt.Println("plaintext", plainText)
 
-	//Find date with Regexp
-	var DMYRegex *regexp.Regexp
-	var MDYRegex *regexp.Regexp
-	//Match Date Month Year. Capture date and year
-	if DMYRegex, err = regexp.Compile(fmt.Sprintf("([0-9]{1,2})[a-z]{0,3}%v([0-9]{2,4})", closestMonthSpelling)); err != nil {
+	//Match month token with optional attached day before and digits after. Capture day, month letters, digits and digits after ordinal suffix.
+	//ex: march, 28mar19, mar272019, march27th2018
+	var monthTokenRegex *regexp.Regexp
+	if monthTokenRegex, err = regexp.Compile(fmt.Sprintf("^(?:([0-9]{1,2})(?:st|nd|rd|th)?)?(%v[a-z]*)([0-9]*)(?:st|nd|rd|th)?([0-9]*)$", regexp.QuoteMeta(closestMonthSpelling))); err != nil {
 		return
 	}
-	//Match Month Date Year. Capture date and year
-	if MDYRegex, err = regexp.Compile(fmt.Sprintf("%v([0-9]{2})[a-z]{0,3}([0-9]{4})", closestMonthSpelling)); err != nil {
-		return
+
+	//Match day and month as adjacent whole tokens of each line so row text is not read as a date. ex: 1015 MARCH ARB 15F, 15T Mayport
+	var day, year int
+	var ok bool
+	var input = strings.ToLower(plainText)
+	for _, line := range strings.Split(input, "\n") {
+		if day, year, ok = findDayAndYearInDateTokens(dateTokens(line), monthTokenRegex, closestMonthSpelling, estimatedMonth, reference); ok {
+			break
+		}
 	}
+	if !ok {
+		//No month name match. Try numeric date.
+		if date, ok = findNumericDateFromPlainText(plainText, reference, slideTZ); ok {
+			return
+		}
 
-	//Remove common OCR errors from and lowercase input string
-	r := strings.NewReplacer(
-		".", "",
-		",", "",
-		" ", "")
-	var input = strings.ToLower(r.Replace(plainText))
-	var regexResult []string
-	if regexResult = DMYRegex.FindStringSubmatch(input); len(regexResult) == 3 {
-		/*
-			fmt.Println(regexResult, len(regexResult))
-			for i, r := range regexResult {
-				fmt.Println(i, r)
-			}
-		*/
-	} else if regexResult = MDYRegex.FindStringSubmatch(input); len(regexResult) == 3 {
-		/*
-			fmt.Println(regexResult, len(regexResult))
-			for i, r := range regexResult {
-				fmt.Println(i, r)
-			}
-		*/
-	} else {
 		//No match, proceed to next processed slide
 		//fmt.Println("no regex match")
 		//fmt.Println(input)
@@ -198,20 +198,335 @@ func findDateFromPlainText(plainText string, closestMonthSpelling string, estima
 		return
 	}
 
-	var capturedYear int
-	var capturedDay int
-	if capturedYear, err = strconv.Atoi(regexResult[2]); err != nil {
+	if year == 0 {
+		year = closestYearForDate(estimatedMonth, day, reference)
+	}
+
+	//If found date is closer to time.Now than other dates, keep it and check other processed slides for other date matches
+	if !isValidDate(year, estimatedMonth, day) {
 		return
 	}
-	if capturedDay, err = strconv.Atoi(regexResult[1]); err != nil {
+	date = time.Date(year, estimatedMonth, day, 0, 0, 0, 0, slideTZ)
+	return
+}
+
+//Split date text into tokens at whitespace and punctuation. ex: "27th march, 2019" is 27th march 2019
+func dateTokens(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == '.' || r == '\''
+	})
+}
+
+//Match day token with optional ordinal suffix. ex: 27, 27th
+var dayTokenRegex = regexp.MustCompile("^([0-9]{1,2})(?:st|nd|rd|th)?$")
+
+//Return day and year of first month token matched by monthTokenRegex with a valid day attached or in the adjacent token.
+//Day before month is day month year (28 MAR 19). Otherwise day after month is month day year (MAR 27th 2019).
+//year is 0 if no plausible year follows. ok is false if no month token has a valid day.
+func findDayAndYearInDateTokens(tokens []string, monthTokenRegex *regexp.Regexp, closestMonthSpelling string, estimatedMonth time.Month, reference time.Time) (day int, year int, ok bool) {
+	tokenAt := func(i int) string {
+		if i < 0 || i >= len(tokens) {
+			return ""
+		}
+		return tokens[i]
+	}
+
+	for i, token := range tokens {
+		regexResult := monthTokenRegex.FindStringSubmatch(token)
+		if regexResult == nil || !isMonthToken(regexResult[2], closestMonthSpelling, estimatedMonth) {
+			continue
+		}
+
+		dayDigits := regexResult[1]
+		if dayResult := dayTokenRegex.FindStringSubmatch(tokenAt(i - 1)); len(dayDigits) == 0 && dayResult != nil {
+			dayDigits = dayResult[1]
+		}
+
+		day, year = 0, 0
+		if len(dayDigits) > 0 {
+			//Day month year. ex: 28mar19, 28 mar 2019
+			day, _ = strconv.Atoi(dayDigits)
+			yearToken := regexResult[3]
+			if len(yearToken) == 0 {
+				yearToken = tokenAt(i + 1)
+			}
+			year, _ = yearFromToken(yearToken, reference)
+		} else if len(regexResult[3]) > 0 {
+			//Month day year. ex: mar272019, march27th2018
+			day, year, _ = dayAndYearFromDigits(regexResult[3], reference)
+			if year == 0 && len(regexResult[4]) > 0 {
+				year, _ = yearFromToken(regexResult[4], reference)
+			} else if year == 0 {
+				year, _ = yearFromToken(tokenAt(i+1), reference)
+			}
+		} else if dayResult := dayTokenRegex.FindStringSubmatch(tokenAt(i + 1)); dayResult != nil {
+			//Month day year. ex: march 27th 2019
+			day, _ = strconv.Atoi(dayResult[1])
+			year, _ = yearFromToken(tokenAt(i+2), reference)
+		}
+
+		//Two digits that are not a valid day are rejected instead of guessing which digit is the day. ex: 45 MAR
+		if day >= 1 && day <= 31 {
+			return day, year, true
+		}
+	}
+	return 0, 0, false
+}
+
+//Return true if letters are closestMonthSpelling or a longer prefix of the month name. ex: mar, march. Not marine
+func isMonthToken(letters string, closestMonthSpelling string, month time.Month) bool {
+	return letters == closestMonthSpelling || strings.HasPrefix(strings.ToLower(month.String()), letters)
+}
+
+//Return year of whole 2 or 4 digit token if within 1 year of reference. ex: 19, 2019. Not 0800 or 1930
+func yearFromToken(token string, reference time.Time) (year int, ok bool) {
+	if _, err := strconv.Atoi(token); err != nil || (len(token) != 2 && len(token) != 4) {
+		return 0, false
+	}
+	if year, ok = yearFromDigits(token, reference); ok && len(token) == 4 && strconv.Itoa(year) != token {
+		return 0, false
+	}
+	return
+}
+
+//Match ISO year-month-day dates
+var isoDateRegex = regexp.MustCompile("\\b([0-9]{4}) ?[-/.] ?([0-9]{1,2}) ?[-/.] ?([0-9]{1,2})\\b")
+
+//Match month/day/year dates. day/month/year if first number is over 12.
+var numericDateRegex = regexp.MustCompile("\\b([0-9]{1,2}) ?[-/.] ?([0-9]{1,2}) ?[-/.] ?([0-9]{4}|[0-9]{2})\\b")
+
+//Return date of first numeric date (03/27/2018, 27/03/18, 2018-03-27) in plain text. ok is false if no valid numeric date found.
+func findNumericDateFromPlainText(plainText string, reference time.Time, slideTZ *time.Location) (date time.Time, ok bool) {
+	var year, month, day int
+	if regexResult := isoDateRegex.FindStringSubmatch(plainText); regexResult != nil {
+		year, _ = strconv.Atoi(regexResult[1])
+		month, _ = strconv.Atoi(regexResult[2])
+		day, _ = strconv.Atoi(regexResult[3])
+	} else if regexResult := numericDateRegex.FindStringSubmatch(plainText); regexResult != nil {
+		month, _ = strconv.Atoi(regexResult[1])
+		day, _ = strconv.Atoi(regexResult[2])
+		if month > 12 {
+			month, day = day, month
+		}
+		//Year is the whole number. ex: 2009 is not 20
+		if year, ok = yearFromDigits(regexResult[3], reference); !ok || (len(regexResult[3]) == 4 && strconv.Itoa(year) != regexResult[3]) {
+			ok = false
+			return
+		}
+	} else {
 		return
 	}
 
-	//If found date is closer to time.Now than other dates, keep it and check other processed slides for other date matches
-	date = time.Date(capturedYear, estimatedMonth, capturedDay, 0, 0, 0, 0, slideTZ)
+	if ok = month >= 1 && month <= 12 && isValidDate(year, time.Month(month), day); ok {
+		date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, slideTZ)
+	}
 	return
 }
 
+//Return year of 4 or 2 digit year at start of digits if within 1 year of reference. ok is false if digits do not start with a plausible year.
+//Digits may be followed by other numbers such as roll call times. ex: 180800 is 2018
+func yearFromDigits(digits string, reference time.Time) (year int, ok bool) {
+	plausible := func(y int) bool {
+		return y >= reference.Year()-1 && y <= reference.Year()+1
+	}
+
+	if len(digits) >= 4 {
+		if year, _ = strconv.Atoi(digits[:4]); plausible(year) {
+			return year, true
+		}
+	}
+	if len(digits) >= 2 {
+		if year, _ = strconv.Atoi(digits[:2]); plausible(2000 + year) {
+			return 2000 + year, true
+		}
+	}
+	return 0, false
+}
+
+//Split digits following month name into day and optional year. Prefer 2 digit day unless only 1 digit day leaves a plausible year.
+//year is 0 if no plausible year follows day. ex: 272018 is 27 2018, 52018 is 5 2018, 12018 is 1 2018, 27 is 27
+func dayAndYearFromDigits(digits string, reference time.Time) (day int, year int, ok bool) {
+	for _, dayLength := range []int{2, 1} {
+		if len(digits) < dayLength {
+			continue
+		}
+		candidateDay, _ := strconv.Atoi(digits[:dayLength])
+		if candidateDay < 1 || candidateDay > 31 {
+			continue
+		}
+		if candidateYear, plausible := yearFromDigits(digits[dayLength:], reference); plausible {
+			return candidateDay, candidateYear, true
+		}
+		if !ok {
+			day, ok = candidateDay, true
+		}
+	}
+	return
+}
+
+//Return year within 1 year of reference that puts month and day closest to reference. Handles December/January rollover of year-less dates.
+func closestYearForDate(month time.Month, day int, reference time.Time) (year int) {
+	year = reference.Year()
+	closest := time.Date(year, month, day, 0, 0, 0, 0, reference.Location())
+	for _, y := range []int{reference.Year() - 1, reference.Year() + 1} {
+		candidate := time.Date(y, month, day, 0, 0, 0, 0, reference.Location())
+		if closerDate(reference, candidate, closest).Equal(candidate) && !candidate.Equal(closest) {
+			year, closest = y, candidate
+		}
+	}
+	return
+}
+
+//Return true if day exists in month of year. ex: 31 Feb is not valid
+func isValidDate(year int, month time.Month, day int) bool {
+	if day < 1 || day > 31 {
+		return false
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Day() == day
+}
+
+//Find date headers of multi-day slides. Each OCR line containing a date of the previous, current or next month is a header for the rows below it.
+//Only lines above tableTop or in a section band row without roll call times are headers so flight rows are not read as dates. ex: 1015 MARCH ARB 15F
+//Year of dates without year is the year closest to photo creation. ex: 31 MAR on photo posted 1 April
+//Dates over 144hr from photo creation are ignored. Returned headers are sorted top to bottom with one header per date.
+func findDateHeadersOfPhotoNodeSlides(slides []Slide, tableTop int, grid TableGrid) (headers []DateHeader, err error) {
+	reference := referenceTimeOfSlides(slides)
+	currentMonth := slides[0].FBCreatedTime.Month()
+	previousMonth := currentMonth - 1
+	if previousMonth < time.January {
+		previousMonth = time.December
+	}
+	nextMonth := currentMonth + 1
+	if nextMonth > time.December {
+		nextMonth = time.January
+	}
+
+	//Topmost header for each date
+	headersByDate := make(map[string]DateHeader)
+
+	for _, s := range slides {
+		lines := ocrWordLines(s.Words)
+
+		//Lines with roll call times are flight rows. Year of header is not a roll call time. ex: 28 MAR 2019
+		var rollCallLines []image.Rectangle
+		for _, line := range lines {
+			var lineRCs []RollCall
+			if lineRCs, err = find24HRFromPlainText(line.Text, s.FBCreatedTime, s.Terminal.Timezone); err != nil {
+				return
+			}
+			for _, rc := range lineRCs {
+				if year, ok := yearFromDigits(rc.Spelling, reference); ok && strconv.Itoa(year) == rc.Spelling {
+					continue
+				}
+				rollCallLines = append(rollCallLines, line.BBox)
+				break
+			}
+		}
+
+		for _, line := range lines {
+			if !isDateHeaderRegion(line.BBox, tableTop, grid, rollCallLines) {
+				continue
+			}
+
+			var lineDates []time.Time
+			if numericDate, ok := findNumericDateFromPlainText(line.Text, s.FBCreatedTime, s.Terminal.Timezone); ok {
+				lineDates = append(lineDates, numericDate)
+			}
+
+			lineText := strings.ToLower(line.Text)
+			for _, month := range []time.Month{previousMonth, currentMonth, nextMonth} {
+				for _, spelling := range []string{month.String(), month.String()[0:3]} {
+					//Skip lines without month so non-header lines are not recorded as date header misses
+					if !strings.Contains(lineText, strings.ToLower(spelling)) {
+						continue
+					}
+
+					var foundDate time.Time
+					if foundDate, err = findDateFromPlainText(line.Text, spelling, month, s.FBCreatedTime, s.Terminal.Timezone); err != nil {
+						return
+					}
+					lineDates = append(lineDates, foundDate)
+				}
+			}
+
+			for _, foundDate := range lineDates {
+				if foundDate.Equal(time.Time{}) || math.Abs(float64(reference.Sub(foundDate))) > float64(time.Hour*144) {
+					continue
+				}
+
+				dateKey := foundDate.Format("2006-01-02")
+				if header, ok := headersByDate[dateKey]; !ok || line.BBox.Min.Y < header.BBox.Min.Y {
+					headersByDate[dateKey] = DateHeader{
+						Date:       foundDate,
+						SharedInfo: SharedInfo{BBox: line.BBox}}
+				}
+			}
+		}
+	}
+
+	for _, header := range headersByDate {
+		headers = append(headers, header)
+	}
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].BBox.Min.Y < headers[j].BBox.Min.Y
+	})
+	return
+}
+
+//Return true if line is above tableTop or in a section band of the table. Section band rows have no roll call time line.
+//Band is the grid row of the line if rows were detected, otherwise the line itself. Lines below detected rows are not in the table.
+func isDateHeaderRegion(lineBBox image.Rectangle, tableTop int, grid TableGrid, rollCallLines []image.Rectangle) bool {
+	band := lineBBox
+	row := -1
+	if grid.hasRows() {
+		if row = grid.cellForRect(lineBBox).Row; row >= 0 {
+			band.Min.Y, band.Max.Y = grid.Rows[row], grid.Rows[row+1]
+		}
+	}
+
+	for _, rcLine := range rollCallLines {
+		if rcLine.Min.Y < band.Max.Y && rcLine.Max.Y > band.Min.Y {
+			return false
+		}
+	}
+
+	//Header region above table
+	if lineBBox.Max.Y <= tableTop {
+		return true
+	}
+	//Section band within table rows
+	return lineBBox.Min.Y >= tableTop && (!grid.hasRows() || row >= 0)
+}
+
+//Return creation time of photo to compare found dates against. Current time if creation time unknown.
+func referenceTimeOfSlides(slides []Slide) time.Time {
+	if slides[0].FBCreatedTime.Equal(time.Time{}) {
+		return time.Now()
+	}
+	return slides[0].FBCreatedTime
+}
+
+//Set date of each RollCall to date of nearest DateHeader above it. headers must be sorted top to bottom.
+//RollCalls above all headers keep their date.
+func assignDateHeadersToRollCalls(headers []DateHeader, rcs []RollCall) {
+	for i := range rcs {
+		var header *DateHeader
+		for j := range headers {
+			if headers[j].BBox.Min.Y > rcs[i].BBox.Min.Y {
+				break
+			}
+			header = &headers[j]
+		}
+		if header == nil {
+			continue
+		}
+
+		t := rcs[i].Time
+		rcs[i].Time = time.Date(header.Date.Year(), header.Date.Month(), header.Date.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+		rcs[i].DateFromHeader = true
+	}
+}
+
 //Return bounds of KEYWORD_XXX in slide.
 func findLabelBoundsOfPhotoNodeSlides(slides []Slide, label string) (bbox image.Rectangle, err error) {
 	//Find closest spelling for label
@@ -223,11 +538,8 @@ func findLabelBoundsOfPhotoNodeSlides(slides []Slide, label string) (bbox image.
 
 	//fmt.Println("closest spelling ", closestDestinationSpelling)
 
-	//Find KEYWORD_DESTINATION bounds in hOCR
-	bboxes, err := getTextBounds(closestDestinationSlide.HOCRText, closestDestinationSpelling)
-	if err != nil {
-		return
-	}
+	//Find KEYWORD_DESTINATION bounds in OCR words
+	bboxes := findWordBounds(closestDestinationSlide.Words, closestDestinationSpelling)
 
 	if len(bboxes) == 0 {
 		err = errors.New("No bbox found for findLabelBoundsOfPhotoNodeSlides")
@@ -248,17 +560,15 @@ func findDestinationsFromSlides(slides []Slide, limitMinY int) (foundDestination
 	//Find location keyword spellings in image pointed to by each slide.
 	for _, s := range slides {
 		var found map[string]TerminalKeywordsResult //map[spelling]{Title, Distance}
-		found = make(map[string]TerminalKeywordsResult)
-		found = findTerminalKeywordsInPlainText(s.PlainText)
+		if found, err = findTerminalKeywordsInPlainText(s.PlainText); err != nil {
+			return
+		}
 
 		//fmt.Println("found keywords", found)
 
-		//Get text bounds from hOCR for each potential spelling found.
+		//Get text bounds from OCR words for each potential spelling found.
 		for spelling, result := range found {
-			var bboxes []image.Rectangle
-			if bboxes, err = getTextBounds(s.HOCRText, spelling); err != nil {
-				return
-			}
+			bboxes := findWordBounds(s.Words, spelling)
 
 			//fmt.Println("found bbox for ", spelling, bboxes, "\nmin y ", limitMinY)
 
@@ -289,25 +599,21 @@ func findDestinationsFromSlides(slides []Slide, limitMinY int) (foundDestination
 //Return a RollCall slice with found and deduplicated RollCalls.
 func findRollCallTimesFromSlides(slides []Slide, estimatedDay time.Time, limitMinY int) (foundRCs []RollCall, foundNoBBoxRCs []RollCall, err error) {
 	for _, s := range slides {
-		var found24HR []time.Time
+		var found24HR []RollCall
 		//fmt.Println("date slide type ", s.SaveType)
 		if found24HR, err = find24HRFromPlainText(s.PlainText, estimatedDay, s.Terminal.Timezone); err != nil {
 			return
 		}
 
-		//Get text bounds from hOCR for each 24HR time text found.
+		//Get text bounds from OCR words for each 24HR time text found.
 		for _, result := range found24HR {
-			var bboxes []image.Rectangle
-			if bboxes, err = getTextBounds(s.HOCRText, result.Format("1504")); err != nil {
-				return
-			}
+			bboxes := findWordBounds(s.Words, result.Spelling)
 
-			//fmt.Println("hocr result for ", result.Format("1504"), bboxes)
+			//fmt.Println("hocr result for ", result.Spelling, bboxes)
 
-			//Not found in hOCR. Probably because time had spaces in it. Keep time as possible time to display to user?
+			//Not found in OCR words. Probably because time had spaces in it. Keep time as possible time to display to user?
 			if len(bboxes) == 0 {
-				foundNoBBoxRCs = append(foundNoBBoxRCs, RollCall{
-					Time: result})
+				foundNoBBoxRCs = append(foundNoBBoxRCs, result)
 			}
 
 			//Add RollCalls to foundRCs slice
@@ -318,9 +624,9 @@ func findRollCallTimesFromSlides(slides []Slide, estimatedDay time.Time, limitMi
 					continue
 				}
 
-				foundRCs = append(foundRCs, RollCall{
-					Time:       result,
-					SharedInfo: SharedInfo{BBox: bbox}})
+				rc := result
+				rc.BBox = bbox
+				foundRCs = append(foundRCs, rc)
 			}
 
 		}
@@ -331,8 +637,10 @@ func findRollCallTimesFromSlides(slides []Slide, estimatedDay time.Time, limitMi
 	return
 }
 
-//Search plain text for 24HR time. Return slice of time.Time of found 24HR time on estimatedDay.
-func find24HRFromPlainText(plainText string, estimatedDay time.Time, slideTZ *time.Location) (found24HR []time.Time, err error) {
+//Search plain text for 24HR time. Return slice of RollCall of found 24HR time on estimatedDay.
+//Times with Z/Zulu suffix are in UTC and set TimeZone ROLLCALL_TIMEZONE_ZULU. Times with L/Local suffix are in slideTZ and set TimeZone ROLLCALL_TIMEZONE_LOCAL.
+//Times without suffix are in slideTZ with empty TimeZone so column labels can decide later.
+func find24HRFromPlainText(plainText string, estimatedDay time.Time, slideTZ *time.Location) (found24HR []RollCall, err error) {
 	//lowercase input string
 	var input = strings.ToLower(plainText)
 	//fmt.Println(input)
@@ -358,12 +666,13 @@ func find24HRFromPlainText(plainText string, estimatedDay time.Time, slideTZ *ti
 	var HR24Regex *regexp.Regexp
 	//Match 24 hr time format
 	//original https://stackoverflow.com/a/1494700
-	if HR24Regex, err = regexp.Compile("\\b(?:[01]\\d|2[0-3])(?:[0-5]\\d)\\b"); err != nil {
+	//Optionally followed by time zone suffix. ex: 0800z, 0800 l, 0800(z), 0800 zulu
+	if HR24Regex, err = regexp.Compile("\\b((?:[01]\\d|2[0-3])(?:[0-5]\\d))(?: ?(z|l|zulu|local)\\b| ?\\((z|l)\\)|\\b)"); err != nil {
 		return
 	}
 
-	var regexResult []string
-	if regexResult = HR24Regex.FindAllString(input, -1); regexResult == nil {
+	var regexResult [][]string
+	if regexResult = HR24Regex.FindAllStringSubmatch(input, -1); regexResult == nil {
 		//No match, proceed to next processed slide
 		//fmt.Println("no RC regex result")
 		return
@@ -374,40 +683,279 @@ func find24HRFromPlainText(plainText string, estimatedDay time.Time, slideTZ *ti
 
 		var capturedHour int
 		var capturedMinute int
-		if capturedHour, err = strconv.Atoi(result[:2]); err != nil {
+		if capturedHour, err = strconv.Atoi(result[1][:2]); err != nil {
 			return
 		}
-		if capturedMinute, err = strconv.Atoi(result[2:]); err != nil {
+		if capturedMinute, err = strconv.Atoi(result[1][2:]); err != nil {
 			return
 		}
 
-		found24HR = append(found24HR, time.Date(
+		rc := RollCall{
+			Spelling: result[0]}
+		location := slideTZ
+		switch result[2] + result[3] {
+		case "z", "zulu":
+			rc.TimeZone = ROLLCALL_TIMEZONE_ZULU
+			location = time.UTC
+		case "l", "local":
+			rc.TimeZone = ROLLCALL_TIMEZONE_LOCAL
+		}
+
+		rc.Time = time.Date(
 			estimatedDay.Year(),
 			estimatedDay.Month(),
 			estimatedDay.Day(),
 			capturedHour,
 			capturedMinute,
 			0, 0,
-			slideTZ))
+			location)
+		found24HR = append(found24HR, rc)
 	}
 	return
 }
 
+//Find roll call column labels marking times as Zulu or local. ex: "Roll Call (L)", "Zulu", "(Z)"
+func findTimeZoneLabelsOfPhotoNodeSlides(slides []Slide) (labels []TimeZoneLabel) {
+	for _, s := range slides {
+		for _, w := range s.Words {
+			text := strings.ToLower(w.Text)
+
+			var timeZone string
+			if strings.Contains(text, "zulu") || strings.HasSuffix(text, "(z)") {
+				timeZone = ROLLCALL_TIMEZONE_ZULU
+			} else if strings.Contains(text, "local") || strings.HasSuffix(text, "(l)") {
+				timeZone = ROLLCALL_TIMEZONE_LOCAL
+			} else {
+				continue
+			}
+
+			labels = append(labels, TimeZoneLabel{
+				TimeZone:   timeZone,
+				SharedInfo: SharedInfo{BBox: w.BBox}})
+		}
+	}
+	return
+}
+
+//Set TimeZone of RollCalls without time zone suffix from the horizontally closest label above in the same column.
+//If no label is in the column, labels that all agree apply to the whole slide. Otherwise times are local.
+//Zulu times are moved from slide time zone to UTC keeping date and clock time.
+func assignTimeZoneLabelsToRollCalls(labels []TimeZoneLabel, rcs []RollCall) {
+	//Time zone applying to whole slide if all labels agree
+	var slideTimeZone string
+	for i, label := range labels {
+		if i == 0 {
+			slideTimeZone = label.TimeZone
+		} else if label.TimeZone != slideTimeZone {
+			slideTimeZone = ""
+			break
+		}
+	}
+
+	for i := range rcs {
+		if len(rcs[i].TimeZone) > 0 {
+			continue
+		}
+
+		rcCenterX := (rcs[i].BBox.Min.X + rcs[i].BBox.Max.X) / 2
+		timeZone := slideTimeZone
+		closestDistance := -1
+		for _, label := range labels {
+			if label.BBox.Min.Y > rcs[i].BBox.Min.Y || label.BBox.Max.X < rcs[i].BBox.Min.X || label.BBox.Min.X > rcs[i].BBox.Max.X {
+				continue
+			}
+			if distance := absInt((label.BBox.Min.X+label.BBox.Max.X)/2 - rcCenterX); closestDistance == -1 || distance < closestDistance {
+				closestDistance = distance
+				timeZone = label.TimeZone
+			}
+		}
+
+		if timeZone == ROLLCALL_TIMEZONE_ZULU {
+			t := rcs[i].Time
+			rcs[i].Time = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+			rcs[i].TimeZone = ROLLCALL_TIMEZONE_ZULU
+		} else {
+			rcs[i].TimeZone = ROLLCALL_TIMEZONE_LOCAL
+		}
+	}
+}
+
+//Remove Zulu RollCalls at the same instant as a local RollCall. Slides listing both local and Zulu times keep the local time.
+func deleteZuluDuplicatesFromRCArray(arrayPointer *[]RollCall) {
+	var kept []RollCall
+	for _, rc := range *arrayPointer {
+		duplicate := false
+		if rc.TimeZone == ROLLCALL_TIMEZONE_ZULU {
+			for _, other := range *arrayPointer {
+				if other.TimeZone == ROLLCALL_TIMEZONE_LOCAL && other.Time.Equal(rc.Time) {
+					duplicate = true
+					break
+				}
+			}
+		}
+		if !duplicate {
+			kept = append(kept, rc)
+		}
+	}
+	*arrayPointer = kept
+}
+
+//Find time column labels in header row. ex: "Show Time", "Roll Call", "Departure"
+//headerBBox is KEYWORD_DESTINATION bbox. Words further than TIME_COLUMN_LABEL_VERTICAL_THRESHOLD from it are not labels. ex: "DEPARTURES" title
+func findTimeColumnLabelsOfPhotoNodeSlides(slides []Slide, headerBBox image.Rectangle, lineHeight int) (labels []TimeColumnLabel) {
+	if headerBBox.Empty() {
+		return
+	}
+	headerThreshold := lineHeightsToPixels(lineHeight, TIME_COLUMN_LABEL_VERTICAL_THRESHOLD)
+
+	for _, s := range slides {
+		for _, w := range s.Words {
+			if getVerticalDistance(w.BBox, headerBBox) > headerThreshold {
+				continue
+			}
+
+			for _, token := range strings.FieldsFunc(strings.ToLower(w.Text), isOCRWordSeparator) {
+				var kind string
+				switch {
+				case strings.HasPrefix(token, "show"):
+					kind = TIME_KIND_SHOW
+				case strings.HasPrefix(token, "roll"):
+					kind = TIME_KIND_ROLLCALL
+				case strings.HasPrefix(token, "depart"), token == "dep", token == "etd":
+					kind = TIME_KIND_DEPARTURE
+				default:
+					continue
+				}
+
+				labels = append(labels, TimeColumnLabel{
+					Kind:       kind,
+					SharedInfo: SharedInfo{BBox: w.BBox}})
+				break
+			}
+		}
+	}
+	return
+}
+
+//Set Kind of each RollCall from the horizontally closest time column label above it. Times without a label in their column are roll calls.
+func assignTimeColumnLabelsToRollCalls(labels []TimeColumnLabel, rcs []RollCall) {
+	for i := range rcs {
+		rcCenterX := (rcs[i].BBox.Min.X + rcs[i].BBox.Max.X) / 2
+		rcs[i].Kind = TIME_KIND_ROLLCALL
+		closestDistance := -1
+		for _, label := range labels {
+			if label.BBox.Min.Y > rcs[i].BBox.Min.Y || label.BBox.Max.X < rcs[i].BBox.Min.X || label.BBox.Min.X > rcs[i].BBox.Max.X {
+				continue
+			}
+			if distance := absInt((label.BBox.Min.X+label.BBox.Max.X)/2 - rcCenterX); closestDistance == -1 || distance < closestDistance {
+				closestDistance = distance
+				rcs[i].Kind = label.Kind
+			}
+		}
+	}
+}
+
+//Split times into roll calls linked to destinations and other times (show, departure) linked to roll calls in the same row.
+//If no roll call column is found, every time is a roll call linked to destinations.
+func splitRollCallsByKind(rcs []RollCall) (rollCalls []RollCall, otherTimes []RollCall) {
+	for _, rc := range rcs {
+		if rc.Kind == TIME_KIND_ROLLCALL {
+			rollCalls = append(rollCalls, rc)
+		} else {
+			otherTimes = append(otherTimes, rc)
+		}
+	}
+	if len(rollCalls) == 0 {
+		for i := range otherTimes {
+			otherTimes[i].Kind = TIME_KIND_ROLLCALL
+		}
+		return otherTimes, nil
+	}
+	return
+}
+
+//Link show and departure times to the RollCall in the same grid row, or on the same text line if grid has no rows.
+//Each RollCall keeps at most one time of each kind.
+func linkTimesToRollCalls(grid TableGrid, rcs []RollCall, otherTimes []RollCall, lineHeight int) {
+	linkThreshold := lineHeightsToPixels(lineHeight, ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD)
+
+	for _, other := range otherTimes {
+		closestIndex := -1
+		closestDistance := 0
+		for rcIndex := range rcs {
+			if grid.hasRows() {
+				if row := grid.cellForRect(other.BBox).Row; row != -1 && row == grid.cellForRect(rcs[rcIndex].BBox).Row {
+					closestIndex = rcIndex
+					break
+				}
+				continue
+			}
+
+			if vertDist := getVerticalDistance(rcs[rcIndex].BBox, other.BBox); vertDist < linkThreshold && (closestIndex == -1 || vertDist < closestDistance) {
+				closestIndex = rcIndex
+				closestDistance = vertDist
+			}
+		}
+		if closestIndex == -1 || rcs[closestIndex].linkedTime(other.Kind) != nil {
+			continue
+		}
+
+		rcs[closestIndex].LinkedTimes = append(rcs[closestIndex].LinkedTimes, other)
+	}
+}
+
+//Set Flight roll call, show and departure times from Kind of rc and its linked times.
+func (f *Flight) setTimesFromRollCall(rc RollCall) {
+	for _, t := range append([]RollCall{rc}, rc.LinkedTimes...) {
+		kindTime := t.Time
+		switch t.Kind {
+		case TIME_KIND_SHOW:
+			f.ShowTime = &kindTime
+		case TIME_KIND_DEPARTURE:
+			f.DepartureTime = &kindTime
+		default:
+			f.RollCall = kindTime
+			f.RollCallTimeZone = t.TimeZone
+		}
+	}
+}
+
+//Return linked time of kind. nil if none linked.
+func (rc RollCall) linkedTime(kind string) *RollCall {
+	for i := range rc.LinkedTimes {
+		if rc.LinkedTimes[i].Kind == kind {
+			return &rc.LinkedTimes[i]
+		}
+	}
+	return nil
+}
+
 //Search slides in Slide slice for SeatsAvailable text.
 //seatsLabelBBox is KEYWORD_SEATS bbox for cropping
 //Return a SeatsAvailable slice with found and deduplicated SeatsAvailable.
 func findSeatsAvailableFromSlides(slides []Slide, seatsLabelBBox image.Rectangle) (foundSAs []SeatsAvailable, err error) {
+	var lineHeight int
+	if lineHeight, err = measureTextLineHeight(slides); err != nil {
+		return
+	}
+	cropBuffer := lineHeightsToPixels(lineHeight, SEATS_CROP_HORIZONTAL_BUFFER)
+
+	//Left edge of cropped image in slide image coordinates
+	cropMinX := seatsLabelBBox.Min.X - cropBuffer
+	if cropMinX < 0 {
+		cropMinX = 0
+	}
+
 	for _, s := range slides {
 
 		//Try to find seats on cropped image
 		//Crop current slide to only show the image column downwards from where seats label was found on
-		if err = runImageMagickDateCropHorizontalProcess(s, image.Point{X: seatsLabelBBox.Min.X - SEATS_CROP_HORIZONTAL_BUFFER, Y: seatsLabelBBox.Max.X + SEATS_CROP_HORIZONTAL_BUFFER}, seatsLabelBBox.Max.Y); err != nil {
+		var cropSlide Slide
+		if cropSlide, err = runImageDateCropHorizontalProcess(s, image.Point{X: seatsLabelBBox.Min.X - cropBuffer, Y: seatsLabelBBox.Max.X + cropBuffer}, seatsLabelBBox.Max.Y); err != nil {
 			return
 		}
 
 		//Run OCR on cropped image for current slide
-		cropSlide := s
-		cropSlide.Suffix = IMAGE_SUFFIX_CROPPED
 		if err = doOCRForSlide(&cropSlide, OCR_WHITELIST_SA); err != nil {
 			return
 		}
@@ -419,27 +967,16 @@ func findSeatsAvailableFromSlides(slides []Slide, seatsLabelBBox image.Rectangle
 
 		//fmt.Println("look SA in slide", s.SaveType, cropSlide.HOCRText)
 
-		//Get text bounds from hOCR for each seat text found.
+		//Get text bounds from OCR words for each seat text found.
 		for _, result := range foundSAs {
 			var bboxes []image.Rectangle
 
-			var searchString string
-			if result.Number == 0 {
-				searchString = fmt.Sprintf("%v", result.Letter)
-			} else {
-				searchString = fmt.Sprintf("%v%v", strconv.Itoa(result.Number), result.Letter)
-			}
-
 			//Find text bounds of found SA text
-			if bboxes, err = getTextBounds(cropSlide.HOCRText, searchString); err != nil {
-				return
-			}
+			bboxes = findWordBounds(cropSlide.Words, result.Spelling)
 
 			for _, bbox := range bboxes {
 				newSA := result
-				bbox.Min.Y += seatsLabelBBox.Max.Y
-				bbox.Max.Y += seatsLabelBBox.Max.Y
-				newSA.BBox = bbox
+				newSA.BBox = bbox.Add(image.Point{X: cropMinX, Y: seatsLabelBBox.Max.Y})
 
 				foundSAs = append(foundSAs, newSA)
 			}
@@ -452,63 +989,25 @@ func findSeatsAvailableFromSlides(slides []Slide, seatsLabelBBox image.Rectangle
 	return
 }
 
-//Search plain text for seat count text. Return slice of found SeatsAvailable without BBox.
+//Search plain text for seat text. Return slice of found SeatsAvailable without BBox.
 func findSeatsFromPlainText(plainText string) (foundSAs []SeatsAvailable, err error) {
-
-	var SeatsCountRegex *regexp.Regexp
-	if SeatsCountRegex, err = regexp.Compile("\\b(?:(?:(\\d{1,3})(f|t|sp)?)|(sp|tbd))\\b"); err != nil {
-		return
-	}
-
 	//lowercase input string
 	var input = strings.ToLower(plainText)
 	//fmt.Println(input)
-	var regexResult [][]string
-	if regexResult = SeatsCountRegex.FindAllStringSubmatch(input, -1); regexResult == nil {
-		//No match, proceed to next processed slide
-		return
-	}
-
-	//fmt.Println("SA regex results len ", len(regexResult))
-
-	for _, result := range regexResult {
-			
-			/*
-			fmt.Println("found SA regex len ", len(result))
-			for n, r := range result {
-				fmt.Println(n, len(r), r)
-			}
-			*/
-			
-
-		var capturedSeatCount int
-		var capturedSeatLetter string //F/T/SP|TBD->SP
-
-		//Check appropriate result indices for seat info
-		if len(result[1]) > 0 { //Check if number captured
-			if capturedSeatCount, err = strconv.Atoi(result[1]); err != nil {
-				return
-			}
 
-			if len(result[2]) > 0 { //Check for letter code
-				capturedSeatLetter = result[2]
-			}
-		} else if len(result[3]) > 0 { //No number, check for letter code in third capture group
-			capturedSeatLetter = result[3]
+	for _, seatsText := range seatsTextRegex.FindAllString(input, -1) {
+		if sa, ok := parseSeatsText(seatsText); ok {
+			foundSAs = append(foundSAs, sa)
 		}
-
-		sa := SeatsAvailable{
-			Number: capturedSeatCount,
-			Letter: capturedSeatLetter}
-
-		foundSAs = append(foundSAs, sa)
 	}
 	return
 }
 
 //For each RollCall - link vertically closest Destination. Create 'anchors' for grouping. Best effort match no threshold.
+//lineHeight is text line height in pixels used to scale link thresholds.
 //Runtime: O(n*mlogm + n*m) n=len(rcs) m=len(destsArray)
-func linkRollCallsToNearestDestinations(rcs []RollCall, destsArray []Destination) {
+func linkRollCallsToNearestDestinations(rcs []RollCall, destsArray []Destination, lineHeight int) {
+	linkThreshold := lineHeightsToPixels(lineHeight, ROLLCALLS_DESTINATION_LINK_VERTICAL_THRESHOLD)
 
 	//Struct for holding Destination and (vertical) distance from target for comparison
 	type DestDist struct {
@@ -527,7 +1026,7 @@ func linkRollCallsToNearestDestinations(rcs []RollCall, destsArray []Destination
 
 			//If vertical distance > ROLLCALLS_DESTINATION_LINK_VERTICAL_THRESHOLD, don't add Dest to distance array.
 			//This is to prevent false 'anchor' when a destination is not found in OCR/fuzzy search and a RollCall is left without the correct 'anchor' Destination because that Destination was not found. Otherwise the RollCall will be matched with a Destination that should be grouped with another Destination (multiple Destination to 1 RollCall)
-			if vertDist > ROLLCALLS_DESTINATION_LINK_VERTICAL_THRESHOLD {
+			if vertDist > linkThreshold {
 				continue
 			}
 
@@ -685,7 +1184,8 @@ func combineDestinationGroupsToAnchorDestinations(groupsP *[]Grouping) {
 }
 
 //For each RollCall - link to SeatsAvailable sharing > threshold vertical pixels. Similar to linkRollCallsToNearestDestinations but reduced for simplicity. There should be one to one relationship for RollCall and SeatsAvailable
-func linkRollCallsToNearestSeatsAvailable(rcs []RollCall, saArray []SeatsAvailable) {
+func linkRollCallsToNearestSeatsAvailable(rcs []RollCall, saArray []SeatsAvailable, lineHeight int) {
+	linkThreshold := lineHeightsToPixels(lineHeight, ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD)
 	/*
 		for _, r := range rcs {
 			fmt.Println(r)
@@ -702,7 +1202,7 @@ func linkRollCallsToNearestSeatsAvailable(rcs []RollCall, saArray []SeatsAvailab
 			vertDist := getVerticalDistance(rcs[rcIndex].BBox, saArray[saIndex].BBox)
 
 			//If intersecting vertically enough, link
-			if vertDist < ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD {
+			if vertDist < linkThreshold {
 				rcs[rcIndex].LinkedSeatsAvailable = &saArray[saIndex]
 				break
 			}
@@ -711,7 +1211,8 @@ func linkRollCallsToNearestSeatsAvailable(rcs []RollCall, saArray []SeatsAvailab
 }
 
 //For each Destination - link to SeatsAvailable sharing > threshold vertical pixels. Similar to linkRollCallsToNearestDestinations but reduced for simplicity. There MAY be a one to one relationship for RollCall and SeatsAvailable. Not guaranteed since there may be only one seat label for multiple destinations (ex: in a grouping).
-func linkDestinationsToNearestSeatsAvailable(dests []Destination, saArray []SeatsAvailable) {
+func linkDestinationsToNearestSeatsAvailable(dests []Destination, saArray []SeatsAvailable, lineHeight int) {
+	linkThreshold := lineHeightsToPixels(lineHeight, ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD)
 	/*
 		for _, r := range dests {
 			fmt.Println(r)
@@ -728,7 +1229,7 @@ func linkDestinationsToNearestSeatsAvailable(dests []Destination, saArray []Seat
 			vertDist := getVerticalDistance(dests[dIndex].BBox, saArray[saIndex].BBox)
 
 			//If intersecting vertically enough, link
-			if vertDist < ROLLCALLS_SEATS_LINK_VERTICAL_THRESHOLD {
+			if vertDist < linkThreshold {
 				dests[dIndex].LinkedSeatsAvailable = &saArray[saIndex]
 				break
 			}
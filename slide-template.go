@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+/*
+ * Per-terminal slide layout templates
+ * Terminals posting the same slide design every day define where the date header and table columns are.
+ */
+
+//Check that template columns are within the slide, ordered left to right and include a destination column.
+func (l SlideLayout) validate() (err error) {
+	if l.columnIndex(LAYOUT_COLUMN_DESTINATION) == -1 {
+		err = errors.New("Slide layout has no destination column.")
+		return
+	}
+	if l.TableTop < 0 || l.TableTop >= 1 || l.RowHeight < 0 || l.RowHeight >= 1 {
+		err = errors.New("Slide layout tableTop and rowHeight must be between 0 and 1.")
+		return
+	}
+
+	previousRight := 0.0
+	for _, c := range l.Columns {
+		if c.Left < previousRight || c.Right <= c.Left || c.Right > 1 {
+			err = fmt.Errorf("Slide layout column %v is not ordered left to right within 0 to 1.", c.Name)
+			return
+		}
+		previousRight = c.Right
+	}
+	return
+}
+
+//Return index of column with name. -1 if not found.
+func (l SlideLayout) columnIndex(name string) int {
+	for i, c := range l.Columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//Convert region to pixel rectangle for image size
+func (r LayoutRegion) rect(width int, height int) image.Rectangle {
+	return image.Rect(int(r.Left*float64(width)), int(r.Top*float64(height)), int(r.Right*float64(width)), int(r.Bottom*float64(height)))
+}
+
+//Return pixel rectangle of column with name from table top to image bottom. Empty if column not in template.
+func (l SlideLayout) columnRect(name string, width int, height int) (columnRect image.Rectangle) {
+	if i := l.columnIndex(name); i != -1 {
+		columnRect = LayoutRegion{
+			Left:   l.Columns[i].Left,
+			Top:    l.TableTop,
+			Right:  l.Columns[i].Right,
+			Bottom: 1}.rect(width, height)
+	}
+	return
+}
+
+//Return true if slides match layout. Destination and seats labels must be found within their template columns above the table top.
+func matchSlideLayout(l SlideLayout, slides []Slide) (matched bool, err error) {
+	if err = l.validate(); err != nil {
+		return
+	}
+
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+	toleranceX := int(LAYOUT_MATCH_TOLERANCE * float64(im.Width))
+	toleranceY := int(LAYOUT_MATCH_TOLERANCE * float64(im.Height))
+
+	labels := map[string]string{
+		LAYOUT_COLUMN_DESTINATION: KEYWORD_DESTINATION,
+		LAYOUT_COLUMN_SEATS:       KEYWORD_SEATS}
+
+	for columnName, keyword := range labels {
+		columnRect := l.columnRect(columnName, im.Width, im.Height)
+		if columnRect.Empty() {
+			continue
+		}
+
+		//Label not found means slide does not match template
+		var labelBBox image.Rectangle
+		if labelBBox, err = findLabelBoundsOfPhotoNodeSlides(slides, keyword); err != nil {
+			err = nil
+			return
+		}
+
+		centerX := (labelBBox.Min.X + labelBBox.Max.X) / 2
+		if centerX < columnRect.Min.X-toleranceX || centerX > columnRect.Max.X+toleranceX || labelBBox.Min.Y > columnRect.Min.Y+toleranceY {
+			return
+		}
+	}
+
+	matched = true
+	return
+}
+
+//Return pixel rectangle of header region for slides
+func (l SlideLayout) headerRectForSlides(slides []Slide) (header image.Rectangle, err error) {
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+	header = l.Header.rect(im.Width, im.Height)
+	return
+}
+
+//Return pixel rectangle of named column for slides. Empty if column not in template.
+func (l SlideLayout) columnRectForSlides(slides []Slide, name string) (columnRect image.Rectangle, err error) {
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+	columnRect = l.columnRect(name, im.Width, im.Height)
+	return
+}
+
+//Create TableGrid from template columns and rows. Rows are detected from rulings if template has no row height.
+func (l SlideLayout) tableGridForSlides(slides []Slide, lineHeight int) (grid TableGrid, err error) {
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+	tableTop := int(l.TableTop * float64(im.Height))
+
+	//Rows from rulings below table top
+	if grid, err = detectTableGridOfPhotoNodeSlides(slides, image.Rect(0, tableTop, im.Width, tableTop), lineHeight); err != nil {
+		return
+	}
+
+	//Fixed height rows
+	if l.RowHeight > 0 {
+		grid.Rows = nil
+		rowHeight := l.RowHeight * float64(im.Height)
+		for y := float64(tableTop); int(y) < im.Height; y += rowHeight {
+			grid.Rows = append(grid.Rows, int(y))
+		}
+		grid.Rows = append(grid.Rows, im.Height)
+	}
+
+	//Template columns
+	columns := []int{0, im.Width}
+	for _, c := range l.Columns {
+		columns = append(columns, int(c.Left*float64(im.Width)), int(c.Right*float64(im.Width)))
+	}
+	grid.Columns = mergeBoundaries(columns, lineHeightsToPixels(lineHeight, GRID_RULING_MERGE_DISTANCE))
+
+	for i := range slides {
+		grid.assignWordCells(slides[i].Words)
+	}
+	return
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"log"
 	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 var db *(sql.DB)
@@ -59,9 +61,20 @@ func createRequiredTables() (err error) {
 			Destination VARCHAR(100),
 			RollCall TIMESTAMP NULL,
 			UnknownRollCallDate BOOLEAN,
+			RollCallTimeZone VARCHAR(1),
+			ShowTime TIMESTAMP NULL,
+			DepartureTime TIMESTAMP NULL,
 			SeatCount INT,
 			SeatType VARCHAR(3), 
+			SeatCode VARCHAR(16),
+			SeatMin INT,
+			SeatMax INT,
+			FirmSeatCount INT,
 			Cancelled BOOLEAN,
+			Status VARCHAR(16),
+			Aircraft VARCHAR(32),
+			Confidence REAL,
+			Provenance TEXT,
 			PhotoSource VARCHAR(2048),
 			SourceDate TIMESTAMP,
 			CONSTRAINT flights_pk PRIMARY KEY (Origin, Destination, RollCall, PhotoSource),
@@ -76,6 +89,24 @@ func createRequiredTables() (err error) {
 		log.Println(FLIGHTS_72HR_TABLE + " table created.")
 	}
 
+	//Add columns missing from flights tables created before columns were added
+	if _, err = db.Exec(fmt.Sprintf(`
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS RollCallTimeZone VARCHAR(1);
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS ShowTime TIMESTAMP NULL;
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS DepartureTime TIMESTAMP NULL;
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS Status VARCHAR(16);
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS Aircraft VARCHAR(32);
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS SeatCode VARCHAR(16);
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS SeatMin INT;
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS SeatMax INT;
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS FirmSeatCount INT;
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS Confidence REAL;
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS Provenance TEXT;
+		`, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE,
+		FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE, FLIGHTS_72HR_TABLE)); err != nil {
+		return
+	}
+
 	/*
 		//Delete indexes
 		if _, err = db.Exec(fmt.Sprintf(`
@@ -119,6 +150,30 @@ func createRequiredTables() (err error) {
 		//log.Println(FLIGHTS_72HR_TABLE + " indexes created.")
 	}
 
+	//Legs are deleted with their flight
+	var flightLegsAlreadyExist bool
+	if flightLegsAlreadyExist, err = setupTable(FLIGHT_LEGS_TABLE, fmt.Sprintf(`
+		CREATE TABLE %v (
+			Origin VARCHAR(100),
+			Destination VARCHAR(100),
+			RollCall TIMESTAMP,
+			PhotoSource VARCHAR(2048),
+			LegIndex INT,
+			LegOrigin VARCHAR(100),
+			LegDestination VARCHAR(100),
+			CONSTRAINT flight_legs_pk PRIMARY KEY (Origin, Destination, RollCall, PhotoSource, LegIndex),
+			CONSTRAINT flight_legs_flight_fk FOREIGN KEY (Origin, Destination, RollCall, PhotoSource) REFERENCES %v(Origin, Destination, RollCall, PhotoSource) ON DELETE CASCADE,
+			CONSTRAINT flight_legs_origin_fk FOREIGN KEY (LegOrigin) REFERENCES Locations(Title),
+			CONSTRAINT flight_legs_dest_fk FOREIGN KEY (LegDestination) REFERENCES Locations(Title));
+		`, FLIGHT_LEGS_TABLE, FLIGHTS_72HR_TABLE)); err != nil {
+		return
+	}
+	if flightLegsAlreadyExist {
+		//log.Println(FLIGHT_LEGS_TABLE + " table already exists.")
+	} else {
+		log.Println(FLIGHT_LEGS_TABLE + " table created.")
+	}
+
 	var photoReportsAlreadyExist bool
 	if photoReportsAlreadyExist, err = setupTable(PHOTOS_REPORTS_TABLE, fmt.Sprintf(`
 		CREATE TABLE %v (
@@ -137,6 +192,54 @@ func createRequiredTables() (err error) {
 		log.Println(PHOTOS_REPORTS_TABLE + " table created.")
 	}
 
+	var photoJobsAlreadyExist bool
+	if photoJobsAlreadyExist, err = setupTable(PHOTO_JOBS_TABLE, fmt.Sprintf(`
+		CREATE TABLE %v (
+			Id VARCHAR(64),
+			Terminal VARCHAR(100),
+			PhotoSource VARCHAR(2048),
+			State VARCHAR(16),
+			SlideDate TIMESTAMP NULL,
+			Flights TEXT,
+			Error VARCHAR(2048),
+			SubmitDate TIMESTAMP,
+			UpdateDate TIMESTAMP,
+			Worker VARCHAR(16),
+			Image BYTEA,
+			CONSTRAINT photo_jobs_pk PRIMARY KEY (Id));
+		`, PHOTO_JOBS_TABLE)); err != nil {
+		return
+	}
+	if photoJobsAlreadyExist {
+		//log.Println(PHOTO_JOBS_TABLE + " table already exists.")
+	} else {
+		log.Println(PHOTO_JOBS_TABLE + " table created.")
+	}
+
+	//Add worker and uploaded image columns to existing photo jobs table
+	if _, err = db.Exec(fmt.Sprintf(`
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS Worker VARCHAR(16);
+		ALTER TABLE %v ADD COLUMN IF NOT EXISTS Image BYTEA;
+		`, PHOTO_JOBS_TABLE, PHOTO_JOBS_TABLE)); err != nil {
+		return
+	}
+
+	var layoutProfilesAlreadyExist bool
+	if layoutProfilesAlreadyExist, err = setupTable(LAYOUT_PROFILES_TABLE, fmt.Sprintf(`
+		CREATE TABLE %v (
+			Terminal VARCHAR(100),
+			Labels TEXT,
+			UpdateDate TIMESTAMP,
+			CONSTRAINT layout_profiles_pk PRIMARY KEY (Terminal));
+		`, LAYOUT_PROFILES_TABLE)); err != nil {
+		return
+	}
+	if layoutProfilesAlreadyExist {
+		//log.Println(LAYOUT_PROFILES_TABLE + " table already exists.")
+	} else {
+		log.Println(LAYOUT_PROFILES_TABLE + " table created.")
+	}
+
 	return
 }
 
@@ -429,7 +532,7 @@ func selectFlightsFromTableWithOriginDestTimeDuration(table string, origin strin
 	//Determine which query to use
 	if len(origin) > 0 && len(dest) == 0 { //Search by only Origin
 		if flightRows, err = db.Query(fmt.Sprintf(`
-			SELECT Origin, Destination, RollCall, UnknownRollCallDate, SeatCount, SeatType, Cancelled, PhotoSource, SourceDate
+			SELECT Origin, Destination, RollCall, UnknownRollCallDate, COALESCE(RollCallTimeZone, ''), ShowTime, DepartureTime, SeatCount, SeatType, COALESCE(SeatCode, ''), COALESCE(SeatMin, SeatCount), COALESCE(SeatMax, SeatCount), COALESCE(FirmSeatCount, CASE WHEN SeatType='f' THEN SeatCount ELSE 0 END), Cancelled, COALESCE(Status, ''), COALESCE(Aircraft, ''), COALESCE(Confidence, 1), COALESCE(Provenance, ''), PhotoSource, SourceDate
 			FROM %v
 			WHERE Origin=$1 AND ((RollCall >= $2 AND RollCall < $3) OR (UnknownRollCallDate IS TRUE AND SourceDate >= $2 AND SourceDate < $3))
 			ORDER BY RollCall, Origin, Destination, SeatCount, SeatType, SourceDate;
@@ -438,7 +541,7 @@ func selectFlightsFromTableWithOriginDestTimeDuration(table string, origin strin
 		}
 	} else if len(origin) == 0 && len(dest) > 0 { //Search by only Destination
 		if flightRows, err = db.Query(fmt.Sprintf(`
-			SELECT Origin, Destination, RollCall, UnknownRollCallDate, SeatCount, SeatType, Cancelled, PhotoSource, SourceDate
+			SELECT Origin, Destination, RollCall, UnknownRollCallDate, COALESCE(RollCallTimeZone, ''), ShowTime, DepartureTime, SeatCount, SeatType, COALESCE(SeatCode, ''), COALESCE(SeatMin, SeatCount), COALESCE(SeatMax, SeatCount), COALESCE(FirmSeatCount, CASE WHEN SeatType='f' THEN SeatCount ELSE 0 END), Cancelled, COALESCE(Status, ''), COALESCE(Aircraft, ''), COALESCE(Confidence, 1), COALESCE(Provenance, ''), PhotoSource, SourceDate
 			FROM %v
 			WHERE Destination=$1 AND ((RollCall >= $2 AND RollCall < $3) OR (UnknownRollCallDate IS TRUE AND SourceDate >= $2 AND SourceDate < $3))
 			ORDER BY RollCall, Origin, Destination, SeatCount, SeatType, SourceDate;
@@ -447,7 +550,7 @@ func selectFlightsFromTableWithOriginDestTimeDuration(table string, origin strin
 		}
 	} else if len(origin) > 0 && len(dest) > 0 { //Search by Origin and Destination
 		if flightRows, err = db.Query(fmt.Sprintf(`
-			SELECT Origin, Destination, RollCall, UnknownRollCallDate, SeatCount, SeatType, Cancelled, PhotoSource, SourceDate
+			SELECT Origin, Destination, RollCall, UnknownRollCallDate, COALESCE(RollCallTimeZone, ''), ShowTime, DepartureTime, SeatCount, SeatType, COALESCE(SeatCode, ''), COALESCE(SeatMin, SeatCount), COALESCE(SeatMax, SeatCount), COALESCE(FirmSeatCount, CASE WHEN SeatType='f' THEN SeatCount ELSE 0 END), Cancelled, COALESCE(Status, ''), COALESCE(Aircraft, ''), COALESCE(Confidence, 1), COALESCE(Provenance, ''), PhotoSource, SourceDate
 			FROM %v
 			WHERE Origin=$1 AND Destination=$2 AND ((RollCall >= $3 AND RollCall < $4) OR (UnknownRollCallDate IS TRUE AND SourceDate >= $3 AND SourceDate < $4))
 			ORDER BY RollCall, Origin, Destination, SeatCount, SeatType, SourceDate;
@@ -456,7 +559,7 @@ func selectFlightsFromTableWithOriginDestTimeDuration(table string, origin strin
 		}
 	} else { //Search all in time duration
 		if flightRows, err = db.Query(fmt.Sprintf(`
-			SELECT Origin, Destination, RollCall, UnknownRollCallDate, SeatCount, SeatType, Cancelled, PhotoSource, SourceDate
+			SELECT Origin, Destination, RollCall, UnknownRollCallDate, COALESCE(RollCallTimeZone, ''), ShowTime, DepartureTime, SeatCount, SeatType, COALESCE(SeatCode, ''), COALESCE(SeatMin, SeatCount), COALESCE(SeatMax, SeatCount), COALESCE(FirmSeatCount, CASE WHEN SeatType='f' THEN SeatCount ELSE 0 END), Cancelled, COALESCE(Status, ''), COALESCE(Aircraft, ''), COALESCE(Confidence, 1), COALESCE(Provenance, ''), PhotoSource, SourceDate
 			FROM %v
 			WHERE (RollCall >= $1 AND RollCall < $2) OR (UnknownRollCallDate IS TRUE AND SourceDate >= $1 AND SourceDate < $2)
 			ORDER BY RollCall, Origin, Destination, SeatCount, SeatType, SourceDate;
@@ -468,11 +571,24 @@ func selectFlightsFromTableWithOriginDestTimeDuration(table string, origin strin
 	var countOfRows = 0
 	for flightRows.Next() {
 		var flight Flight
+		var provenanceText string
 
-		if err = flightRows.Scan(&flight.Origin, &flight.Destination, &flight.RollCall, &flight.UnknownRollCallDate, &flight.SeatCount, &flight.SeatType, &flight.Cancelled, &flight.PhotoSource, &flight.SourceDate); err != nil {
+		if err = flightRows.Scan(&flight.Origin, &flight.Destination, &flight.RollCall, &flight.UnknownRollCallDate, &flight.RollCallTimeZone, &flight.ShowTime, &flight.DepartureTime, &flight.SeatCount, &flight.SeatType, &flight.SeatCode, &flight.SeatMin, &flight.SeatMax, &flight.FirmSeatCount, &flight.Cancelled, &flight.Status, &flight.Aircraft, &flight.Confidence, &provenanceText, &flight.PhotoSource, &flight.SourceDate); err != nil {
 			return
 		}
 
+		//Flights stored before confidence was scored have no provenance and are not hidden by confidence
+		if len(provenanceText) > 0 {
+			if err = json.Unmarshal([]byte(provenanceText), &flight.Provenance); err != nil {
+				return
+			}
+		}
+
+		//Flights stored before status was recorded
+		if len(flight.Status) == 0 {
+			flight.Status = FLIGHT_STATUS_SCHEDULED
+		}
+
 		flights = append(flights, flight)
 		countOfRows++
 	}
@@ -491,13 +607,26 @@ func insertFlightsIntoTable(table string, flights []Flight) (err error) {
 
 	//Insert flights into table
 	var rowsAffected int64
+	//Nullable times in UTC
+	utcOrNil := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		utc := t.In(time.UTC)
+		return &utc
+	}
+
 	for _, flight := range flights {
+		var provenanceJSON []byte
+		if provenanceJSON, err = json.Marshal(flight.Provenance); err != nil {
+			return
+		}
 
 		var result sql.Result
 		if result, err = db.Exec(fmt.Sprintf(`
-			INSERT INTO %v (Origin, Destination, RollCall, UnknownRollCallDate, SeatCount, SeatType, Cancelled, PhotoSource, SourceDate) 
-	    	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
-	 		`, table), flight.Origin, flight.Destination, flight.RollCall.In(time.UTC), flight.UnknownRollCallDate, flight.SeatCount, flight.SeatType, false, flight.PhotoSource, flight.SourceDate.In(time.UTC)); err != nil {
+			INSERT INTO %v (Origin, Destination, RollCall, UnknownRollCallDate, RollCallTimeZone, ShowTime, DepartureTime, SeatCount, SeatType, SeatCode, SeatMin, SeatMax, FirmSeatCount, Cancelled, Status, Aircraft, Confidence, Provenance, PhotoSource, SourceDate) 
+	    	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20);
+	 		`, table), flight.Origin, flight.Destination, flight.RollCall.In(time.UTC), flight.UnknownRollCallDate, flight.RollCallTimeZone, utcOrNil(flight.ShowTime), utcOrNil(flight.DepartureTime), flight.SeatCount, flight.SeatType, flight.SeatCode, flight.SeatMin, flight.SeatMax, flight.FirmSeatCount, flight.Cancelled, flight.Status, flight.Aircraft, flight.Confidence, string(provenanceJSON), flight.PhotoSource, flight.SourceDate.In(time.UTC)); err != nil {
 			return
 		}
 
@@ -513,6 +642,89 @@ func insertFlightsIntoTable(table string, flights []Flight) (err error) {
 	return
 }
 
+//Insert Flight.Legs of []Flight into table. Flights must already be inserted into flights table.
+//Legs with a stop not in locations table are skipped and logged so the stop foreign key does not fail the insert.
+func insertFlightLegsIntoTable(table string, flights []Flight) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var rowsAffected int64
+	for _, flight := range flights {
+		for legIndex, leg := range flight.Legs {
+			var result sql.Result
+			if result, err = db.Exec(fmt.Sprintf(`
+				INSERT INTO %v (Origin, Destination, RollCall, PhotoSource, LegIndex, LegOrigin, LegDestination) 
+		    	SELECT $1::VARCHAR, $2::VARCHAR, $3::TIMESTAMP, $4::VARCHAR, $5::INT, $6::VARCHAR, $7::VARCHAR
+		    	WHERE EXISTS (SELECT 1 FROM %v WHERE Title = $6) AND EXISTS (SELECT 1 FROM %v WHERE Title = $7);
+		 		`, table, LOCATIONS_TABLE, LOCATIONS_TABLE), flight.Origin, flight.Destination, flight.RollCall.In(time.UTC), flight.PhotoSource, legIndex, leg.Origin, leg.Destination); err != nil {
+				return
+			}
+
+			var affected int64
+			if affected, err = result.RowsAffected(); err != nil {
+				return
+			}
+			if affected == 0 {
+				log.Printf("Skipped leg %v %v to %v of flight %v to %v. Stop not in %v table.\n", legIndex, leg.Origin, leg.Destination, flight.Origin, flight.Destination, LOCATIONS_TABLE)
+			}
+			rowsAffected += affected
+		}
+	}
+
+	fmt.Printf("INSERT []FlightLeg to %v\n%v rows affected\n", table, rowsAffected)
+
+	return
+}
+
+//Select legs of flights from table and set Flight.Legs in order.
+func selectFlightLegsOfFlightsFromTable(table string, flights []Flight) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	//Flight primary key -> index in flights
+	flightKey := func(origin string, destination string, rollCall time.Time, photoSource string) string {
+		return fmt.Sprintf("%v|%v|%v|%v", origin, destination, rollCall.Unix(), photoSource)
+	}
+	flightIndexes := make(map[string]int)
+	var photoSources []string
+	for fIndex, f := range flights {
+		flightIndexes[flightKey(f.Origin, f.Destination, f.RollCall, f.PhotoSource)] = fIndex
+		photoSources = append(photoSources, f.PhotoSource)
+	}
+	if len(photoSources) == 0 {
+		return
+	}
+
+	var legRows *sql.Rows
+	if legRows, err = db.Query(fmt.Sprintf(`
+		SELECT Origin, Destination, RollCall, PhotoSource, LegOrigin, LegDestination
+		FROM %v
+		WHERE PhotoSource = ANY($1)
+		ORDER BY Origin, Destination, RollCall, PhotoSource, LegIndex;
+		`, table), pq.Array(photoSources)); err != nil {
+		return
+	}
+	defer legRows.Close()
+
+	for legRows.Next() {
+		var origin, destination, photoSource string
+		var rollCall time.Time
+		var leg FlightLeg
+		if err = legRows.Scan(&origin, &destination, &rollCall, &photoSource, &leg.Origin, &leg.Destination); err != nil {
+			return
+		}
+
+		if fIndex, ok := flightIndexes[flightKey(origin, destination, rollCall, photoSource)]; ok {
+			flights[fIndex].Legs = append(flights[fIndex].Legs, leg)
+		}
+	}
+	err = legRows.Err()
+
+	return
+}
+
 //Delete flights for time.Time with origin from a originTerminal. Accounts for current time to avoid deleting past flights.
 //Pass in time in origin Terminal TZ
 //Expect targetDay to be 00:00:00 time.
@@ -660,3 +872,262 @@ func insertPhotoReportIntoTable(table string, pr PhotoReport) (err error) {
 
 	return
 }
+
+//Insert or update PhotoJob in table. Flights are stored as JSON.
+func insertPhotoJobIntoTable(table string, job PhotoJob) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var flightsJSON []byte
+	if flightsJSON, err = json.Marshal(job.Flights); err != nil {
+		return
+	}
+
+	var slideDate *time.Time
+	if !job.SlideDate.Equal(time.Time{}) {
+		utcSlideDate := job.SlideDate.In(time.UTC)
+		slideDate = &utcSlideDate
+	}
+
+	//Truncate error on a rune boundary to fit column
+	errorText := job.Error
+	if len(errorText) > 2048 {
+		end := 2048
+		for end > 0 && !utf8.RuneStart(errorText[end]) {
+			end--
+		}
+		errorText = errorText[:end]
+	}
+
+	if _, err = db.Exec(fmt.Sprintf(`
+		INSERT INTO %v (Id, Terminal, PhotoSource, State, SlideDate, Flights, Error, SubmitDate, UpdateDate, Worker)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (Id) DO UPDATE SET
+			State = EXCLUDED.State,
+			SlideDate = EXCLUDED.SlideDate,
+			Flights = EXCLUDED.Flights,
+			Error = EXCLUDED.Error,
+			UpdateDate = EXCLUDED.UpdateDate,
+			Worker = EXCLUDED.Worker;
+		`, table), job.Id, job.TerminalTitle, job.PhotoSource, job.State, slideDate, string(flightsJSON), errorText, job.SubmitDate.In(time.UTC), job.UpdateDate.In(time.UTC), job.Worker); err != nil {
+		return
+	}
+
+	return
+}
+
+//Insert queued PhotoJob for uploaded photo with image data in table. Image is read by the worker that claims the job.
+func insertUploadPhotoJobIntoTable(table string, job PhotoJob, imageData []byte) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	if _, err = db.Exec(fmt.Sprintf(`
+		INSERT INTO %v (Id, Terminal, PhotoSource, State, Flights, Error, SubmitDate, UpdateDate, Worker, Image)
+			VALUES ($1, $2, $3, $4, 'null', '', $5, $6, '', $7);
+		`, table), job.Id, job.TerminalTitle, job.PhotoSource, job.State, job.SubmitDate.In(time.UTC), job.UpdateDate.In(time.UTC), imageData); err != nil {
+		return
+	}
+
+	return
+}
+
+//Count queued PhotoJobs with photo id starting with prefix in table.
+func selectQueuedPhotoJobCountFromTable(table string, photoSourcePrefix string) (count int, err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	if err = db.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*) FROM %v WHERE State=$1 AND PhotoSource LIKE $2;
+		`, table), JOB_STATE_QUEUED, photoSourcePrefix+"%").Scan(&count); err != nil {
+		return
+	}
+
+	return
+}
+
+//Claim oldest queued PhotoJob with photo id starting with prefix for worker. Claimed job state is JOB_STATE_OCR.
+//Jobs locked by another worker are skipped. found is false if no job is queued.
+func claimQueuedPhotoJobFromTable(table string, photoSourcePrefix string, worker string) (job PhotoJob, found bool, err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	if err = db.QueryRow(fmt.Sprintf(`
+		UPDATE %v SET State=$1, Worker=$2, UpdateDate=$3
+		WHERE Id = (
+			SELECT Id FROM %v
+			WHERE State=$4 AND PhotoSource LIKE $5
+			ORDER BY SubmitDate
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED)
+		RETURNING Id, Terminal, PhotoSource, State, SubmitDate, UpdateDate, Worker;
+		`, table, table), JOB_STATE_OCR, worker, time.Now().In(time.UTC), JOB_STATE_QUEUED, photoSourcePrefix+"%").Scan(&job.Id, &job.TerminalTitle, &job.PhotoSource, &job.State, &job.SubmitDate, &job.UpdateDate, &job.Worker); err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		return
+	}
+	found = true
+
+	return
+}
+
+//SELECT uploaded image of PhotoJob for photo id from table.
+func selectPhotoJobImageFromTable(table string, photoSource string) (imageData []byte, err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	if err = db.QueryRow(fmt.Sprintf(`
+		SELECT Image FROM %v WHERE PhotoSource=$1 AND Image IS NOT NULL;
+		`, table), photoSource).Scan(&imageData); err != nil {
+		return
+	}
+
+	return
+}
+
+//Set unfinished PhotoJobs of worker to JOB_STATE_FAILED. Run at startup since jobs of a stopped process never finish.
+func updateUnfinishedPhotoJobsOfWorkerToFailedInTable(table string, worker string) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var result sql.Result
+	if result, err = db.Exec(fmt.Sprintf(`
+		UPDATE %v SET State=$1, Error=$2, UpdateDate=$3
+		WHERE Worker=$4 AND State IN ($5, $6, $7);
+		`, table), JOB_STATE_FAILED, "Worker stopped before job finished.", time.Now().In(time.UTC), worker, JOB_STATE_QUEUED, JOB_STATE_OCR, JOB_STATE_PARSING); err != nil {
+		return
+	}
+
+	var affected int64
+	if affected, err = result.RowsAffected(); err != nil {
+		return
+	}
+
+	fmt.Printf("UPDATE unfinished %v photo jobs to failed\n%v rows affected\n", worker, affected)
+	return
+}
+
+//SELECT PhotoJob with id from table. found is false if no job with id.
+func selectPhotoJobFromTable(table string, id string) (job PhotoJob, found bool, err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var slideDate *time.Time
+	var flightsJSON string
+	if err = db.QueryRow(fmt.Sprintf(`
+		SELECT Id, Terminal, PhotoSource, State, SlideDate, Flights, Error, SubmitDate, UpdateDate, COALESCE(Worker, '')
+		FROM %v
+		WHERE Id=$1;
+		`, table), id).Scan(&job.Id, &job.TerminalTitle, &job.PhotoSource, &job.State, &slideDate, &flightsJSON, &job.Error, &job.SubmitDate, &job.UpdateDate, &job.Worker); err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		return
+	}
+	found = true
+
+	if slideDate != nil {
+		job.SlideDate = *slideDate
+	}
+
+	if err = json.Unmarshal([]byte(flightsJSON), &job.Flights); err != nil {
+		return
+	}
+
+	return
+}
+
+//Check if table has a PhotoJob for photo id from terminal.
+func selectPhotoJobExistsForPhotoFromTable(table string, terminalTitle string, photoSource string) (found bool, err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	if err = db.QueryRow(fmt.Sprintf(`
+		SELECT EXISTS (SELECT 1 FROM %v WHERE Terminal=$1 AND PhotoSource=$2);
+		`, table), terminalTitle, photoSource).Scan(&found); err != nil {
+		return
+	}
+
+	return
+}
+
+//Delete PhotoJobs submitted before time from table.
+func deletePhotoJobsFromTableBeforeTime(table string, before time.Time) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var result sql.Result
+	if result, err = db.Exec(fmt.Sprintf(`
+		DELETE FROM %v
+		WHERE SubmitDate < $1;
+		`, table), before.In(time.UTC)); err != nil {
+		return
+	}
+
+	var affected int64
+	if affected, err = result.RowsAffected(); err != nil {
+		return
+	}
+
+	fmt.Printf("DELETE photo jobs before %v\n%v rows affected\n", before, affected)
+	return
+}
+
+//INSERT or UPDATE LayoutProfile for terminal in table.
+func upsertLayoutProfileIntoTable(table string, profile LayoutProfile) (err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var labelsJSON []byte
+	if labelsJSON, err = json.Marshal(profile.Labels); err != nil {
+		return
+	}
+
+	if _, err = db.Exec(fmt.Sprintf(`
+		INSERT INTO %v (Terminal, Labels, UpdateDate)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (Terminal) DO UPDATE SET
+			Labels = EXCLUDED.Labels,
+			UpdateDate = EXCLUDED.UpdateDate;
+		`, table), profile.TerminalTitle, string(labelsJSON), profile.UpdateDate.In(time.UTC)); err != nil {
+		return
+	}
+
+	return
+}
+
+//SELECT LayoutProfile for terminal from table. found is false if terminal has no profile.
+func selectLayoutProfileFromTable(table string, terminalTitle string) (profile LayoutProfile, found bool, err error) {
+	if err = checkDatabaseHandleValid(db); err != nil {
+		return
+	}
+
+	var labelsJSON string
+	if err = db.QueryRow(fmt.Sprintf(`
+		SELECT Terminal, Labels, UpdateDate
+		FROM %v
+		WHERE Terminal=$1;
+		`, table), terminalTitle).Scan(&profile.TerminalTitle, &labelsJSON, &profile.UpdateDate); err != nil {
+		if err == sql.ErrNoRows {
+			err = nil
+		}
+		return
+	}
+	found = true
+
+	if err = json.Unmarshal([]byte(labelsJSON), &profile.Labels); err != nil {
+		return
+	}
+
+	return
+}
@@ -95,21 +95,78 @@ type TerminalLocation struct {
 	Longitude float64 `json:"longitude"`
 }
 
+//Slide layout template in terminal file. Coordinates are fractions (0-1) of slide image width and height.
+type SlideLayout struct {
+	Header    LayoutRegion   `json:"header"`    //Region containing the slide date
+	TableTop  float64        `json:"tableTop"`  //Top of the first flight row below the column labels
+	RowHeight float64        `json:"rowHeight"` //Height of each flight row. 0 detects rows from rulings.
+	Columns   []LayoutColumn `json:"columns"`   //Table columns in expected left to right order
+}
+
+//Rectangular region of slide layout template
+type LayoutRegion struct {
+	Left   float64 `json:"left"`
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+}
+
+//Table column of slide layout template. Name is LAYOUT_COLUMN_XXX or any other name for ignored columns.
+type LayoutColumn struct {
+	Name  string  `json:"name"`
+	Left  float64 `json:"left"`
+	Right float64 `json:"right"`
+}
+
+//Layout profile learned from confidently parsed slides of a terminal
+type LayoutProfile struct {
+	TerminalTitle string                  `json:"terminal"`
+	Labels        map[string]LearnedLabel `json:"labels"` //Keyed by KEYWORD_XXX label
+	UpdateDate    time.Time               `json:"updateDate"`
+}
+
+//Average position of a label over Samples parsed slides. Region is fraction (0-1) of slide image width and height.
+type LearnedLabel struct {
+	Region  LayoutRegion `json:"region"`
+	Samples int          `json:"samples"`
+}
+
 //Terminal representation
 //Used for both Terminal list and keywords list depending on which files loaded from.
 type Terminal struct {
 	Title    string           `json:"title"`
 	Id       string           `json:"id"`
 	Keywords []string         `json:"keywords"`
+	ICAO     string           `json:"icao"` //4 letter ICAO airport code. ex: KDOV
+	IATA     string           `json:"iata"` //3 letter IATA airport code. ex: DOV
 	Location TerminalLocation `json:"location"`
 	Timezone *time.Location
 
+	//Name of PhotoSource to read schedule photos from. Empty uses PHOTO_SOURCE_DEFAULT.
+	PhotoSourceName string `json:"photoSource"`
+
+	//Names of ImageVariants fed to OCR. Empty uses defaultImageVariantNames.
+	VariantNames []string `json:"variants"`
+
+	//Rectify photos of screens before OCR even if -rectify is not set
+	Rectify bool `json:"rectify"`
+
+	//Slide layout template used when slide labels are found where template expects. Nil uses generic layout detection.
+	Layout *SlideLayout `json:"layout"`
+
 	PageInfoEdge
 
 	TimezoneOffset int    `json:"tzOffset"` //Used to debug TZ offset export
 	TimezoneTitle string `json:"tzTitle"`
 }
 
+//Photo listed by a PhotoSource
+type SourcePhoto struct {
+	Id          string    //Unique id of photo within its PhotoSource. Used as Slide.FBNodeId.
+	CreatedTime time.Time //Time photo was posted/created
+	Location    string    //Source specific locator of photo (file path). Optional.
+}
+
 //Processed version of downloaded photo
 type Slide struct {
 	SaveType      SaveImageType
@@ -119,8 +176,32 @@ type Slide struct {
 	FBNodeId      string
 	FBCreatedTime time.Time
 
+	//Transform from slide image to original photo coordinates if photo was rectified
+	Transform ImageTransform
+
+	//Image kept in memory by goImageProcessor. nil if image is only saved at photoPath.
+	Image image.Image
+
 	PlainText string
 	HOCRText  string
+	Words     []OCRWord
+}
+
+//Result of OCREngine recognizing an image
+type OCRResult struct {
+	PlainText string
+	HOCRText  string
+	Words     []OCRWord
+}
+
+//Word recognized by OCR with bounding box
+type OCRWord struct {
+	Text       string
+	BBox       image.Rectangle
+	LineId     string //hOCR ocr_line id
+	BlockId    string //hOCR ocr_carea id
+	Confidence int    //0-100
+	Cell       GridCell //Table cell of word set by TableGrid.assignWordCells
 }
 
 /*
@@ -145,6 +226,42 @@ type Destination struct {
 	//SeatsAvailable for Destination.
 	//NOT used to indicate 'anchor'. Use LinkedRollCall to indicate anchor.
 	LinkedSeatsAvailable *SeatsAvailable
+
+	//FLIGHT_STATUS_XXX found in Destination row. Empty if scheduled.
+	Status string
+
+	//AircraftType.Name found in Destination row. Empty if not listed.
+	Aircraft string
+}
+
+//Date header of a day section in multi-day slide. BBox is the OCR line containing the date.
+type DateHeader struct {
+	Date time.Time
+	SharedInfo
+}
+
+//Roll call column label marking times as Zulu or local
+type TimeZoneLabel struct {
+	TimeZone string //ROLLCALL_TIMEZONE_XXX
+	SharedInfo
+}
+
+//Aircraft or mission text on a flight row. ex: C-17, Patriot Express
+type AircraftMarker struct {
+	Aircraft string //AircraftType.Name
+	SharedInfo
+}
+
+//Status word on a flight row. ex: CANX, DELAYED, FULL
+type FlightStatusMarker struct {
+	Status string //FLIGHT_STATUS_XXX
+	SharedInfo
+}
+
+//Column label of show, roll call or departure times
+type TimeColumnLabel struct {
+	Kind string //TIME_KIND_XXX
+	SharedInfo
 }
 
 //RollCall representation
@@ -152,14 +269,35 @@ type RollCall struct {
 	Time time.Time
 	SharedInfo
 
+	//Text matched on slide
+	Spelling string
+
+	//ROLLCALL_TIMEZONE_XXX interpretation of Time. Empty until time zone labels are applied.
+	TimeZone string
+
+	//TIME_KIND_XXX column of Time. Empty until time column labels are applied.
+	Kind string
+
+	//Show and departure times in the same row as RollCall
+	LinkedTimes []RollCall
+
+	//Date of Time is from DateHeader above RollCall
+	DateFromHeader bool
+
 	//SeatsAvailabe that is in the same row as RollCall
 	LinkedSeatsAvailable *SeatsAvailable
 }
 
 //SeatsAvailable representation
 type SeatsAvailable struct {
-	Number int
-	Letter string
+	Number int    //Largest seat count
+	Letter string //f/t/sp/tbd as written on slide
+
+	Spelling string //Seat text found. ex: 15f/30t, 20-40, full
+	Code     string //SEAT_CODE_XXX
+	Min      int    //Smallest seat count of range. Firm seat count of SEAT_CODE_FIRM_TENTATIVE.
+	Max      int    //Largest seat count of range. Firm and tentative seats total of SEAT_CODE_FIRM_TENTATIVE.
+	Firm     int    //Firm seat count
 	SharedInfo
 }
 
@@ -198,15 +336,48 @@ func (g *Grouping) updateBBox() {
 
 //Representation of a specific flight
 type Flight struct {
-	Origin              string    `json:"origin"`
-	Destination         string    `json:"destination"`
-	RollCall            time.Time `json:"rollCall"`
-	UnknownRollCallDate bool      `json:"unknownRollCallDate"` //boolean indicates if RollCall date is unknown. False value may still indicate time of day was found.
-	SeatCount           int       `json:"seatCount"`
-	SeatType            string    `json:"seatType"`
-	Cancelled           bool      `json:"cancelled"`
-	PhotoSource         string    `json:"photoSource"` //FB node id
-	SourceDate          time.Time `json:"sourceDate"`  //FB node created time
+	Origin              string           `json:"origin"`
+	Destination         string           `json:"destination"`
+	RollCall            time.Time        `json:"rollCall"`
+	UnknownRollCallDate bool             `json:"unknownRollCallDate"` //boolean indicates if RollCall date is unknown. False value may still indicate time of day was found.
+	RollCallTimeZone    string           `json:"rollCallTimeZone"`    //ROLLCALL_TIMEZONE_XXX interpretation of roll call time on slide
+	ShowTime            *time.Time       `json:"showTime"`            //nil if slide has no show time for flight
+	DepartureTime       *time.Time       `json:"departureTime"`       //nil if slide has no departure time for flight
+	SeatCount           int              `json:"seatCount"`
+	SeatType            string           `json:"seatType"`
+	SeatCode            string           `json:"seatCode"` //SEAT_CODE_XXX. Empty if no seats found for flight.
+	SeatMin             int              `json:"seatMin"`  //SeatsAvailable.Min
+	SeatMax             int              `json:"seatMax"`  //SeatsAvailable.Max
+	FirmSeatCount       int              `json:"firmSeatCount"`
+	Cancelled           bool             `json:"cancelled"`
+	Status              string           `json:"status"`     //FLIGHT_STATUS_XXX
+	Aircraft            string           `json:"aircraft"`   //AircraftType.Name. Empty if not listed on slide.
+	Legs                []FlightLeg      `json:"legs"`       //Ordered legs from Origin to Destination. Empty if no stops listed on slide.
+	Confidence          float64          `json:"confidence"` //0-1 score of how reliably flight was parsed
+	Provenance          FlightProvenance `json:"provenance"`
+	PhotoSource         string           `json:"photoSource"` //FB node id
+	SourceDate          time.Time        `json:"sourceDate"`  //FB node created time
+}
+
+//Source text and bboxes in original photo coordinates a Flight was parsed from, and the scores making up Flight.Confidence
+type FlightProvenance struct {
+	DestinationSpelling string          `json:"destinationSpelling"`
+	DestinationBBox     image.Rectangle `json:"destinationBBox"`
+	RollCallSpelling    string          `json:"rollCallSpelling"` //Empty if no RollCall linked
+	RollCallBBox        image.Rectangle `json:"rollCallBBox"`
+	SeatsSpelling       string          `json:"seatsSpelling"` //Empty if no SeatsAvailable linked
+	SeatsBBox           image.Rectangle `json:"seatsBBox"`
+
+	OCRScore      float64 `json:"ocrScore"`      //Mean OCR word confidence of destination and roll call text
+	SpellingScore float64 `json:"spellingScore"` //Destination fuzzy match spelling distance
+	LinkScore     float64 `json:"linkScore"`     //Vertical distance of roll call and seats to destination
+	DateScore     float64 `json:"dateScore"`     //1 if roll call date known
+}
+
+//Leg of a multi stop flight. ex: RAMSTEIN - ROTA - NORFOLK has legs Ramstein to Rota and Rota to Norfolk
+type FlightLeg struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
 }
 
 //Representation of Photo Report by user
@@ -218,6 +389,22 @@ type PhotoReport struct {
 	IPAddress           string
 }
 
+//Processing job for a photo from a PhotoSource or uploaded through POST /photos
+type PhotoJob struct {
+	Id            string      `json:"id"`
+	State         string      `json:"state"` //JOB_STATE_XXX
+	Terminal      Terminal    `json:"-"`
+	TerminalTitle string      `json:"terminal"`
+	Photo         SourcePhoto `json:"-"`
+	Worker        string      `json:"-"` //procMode of process running job. Empty for uploaded photos not yet claimed by a worker.
+	PhotoSource   string      `json:"photoSource"` //Photo id. Matches Flight.PhotoSource
+	SlideDate     time.Time   `json:"slideDate"`   //Date found by findDateOfPhotoNodeSlides. Zero if date not found.
+	Flights       []Flight    `json:"flights"`
+	Error         string      `json:"error"`
+	SubmitDate    time.Time   `json:"submitDate"`
+	UpdateDate    time.Time   `json:"updateDate"`
+}
+
 /*
  * Representation of web server data
  */
@@ -231,4 +418,6 @@ type SAResponse struct {
 	Locations []string `json:"locations"`
 	Terminals []Terminal `json:"terminals"`
 	Data string	`json:"data"`
+	JobId string `json:"jobId"`
+	Job *PhotoJob `json:"job"`
 }
@@ -2,15 +2,12 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"image"
-	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -152,7 +149,7 @@ func updateAllTerminalsFlights(terminalMap map[string]Terminal) {
 	setLiveTotalTerminals(len(terminalMap))
 
 	//Create fuzzy models for lookup
-	if err := createFuzzyModels(); err != nil {
+	if err := acquireFuzzyModels(); err != nil {
 		log.Fatal(err)
 	}
 
@@ -165,7 +162,7 @@ func updateAllTerminalsFlights(terminalMap map[string]Terminal) {
 	}
 
 	//Tear down fuzzy models to release memory
-	destroyFuzzyModels()
+	releaseFuzzyModels()
 
 	endTime = time.Now()
 
@@ -187,31 +184,23 @@ func updateTerminalFlights(targetTerminal Terminal) (err error) {
 		return
 	}
 
-	//Request Albums edge from Graph API
-	//Try to find 72 hour album id. If no 72 hour album found, use terminal id.
-	var albumId string
-	if albumId, err = find72HrAlbumId(targetTerminal); err != nil {
+	//Get PhotoSource for terminal
+	var source PhotoSource
+	if source, err = photoSourceForTerminal(targetTerminal); err != nil {
 		return
 	}
-	if len(albumId) == 0 {
-		albumId = targetTerminal.Id
-		displayErrorForTerminal(targetTerminal, "72 hour album not found.")
-	} else {
-		displayMessageForTerminal(targetTerminal, "72 hour album found id " + albumId + ".")
-		incrementTerminalsWith72HRAlbum()
-	}
 
-	//Request Photos edge from Graph API
-	var photosEdge PhotosEdge
-	if photosEdge, err = getPhotosEdge(albumId); err != nil {
+	//Request recent photos from PhotoSource
+	var photos []SourcePhoto
+	if photos, err = source.RecentPhotos(targetTerminal); err != nil {
 		return
 	}
 
-	//Look at the photo nodes returned by the photos edge
+	//Look at the most recent photos returned by the PhotoSource
 	var limit int
-	limit = 4
-	if len(photosEdge.Data) < limit {
-		limit = len(photosEdge.Data)
+	limit = PHOTO_SOURCE_RECENT_LIMIT
+	if len(photos) < limit {
+		limit = len(photos)
 	}
 
 	//Spawn goroutine to download and process each image
@@ -232,17 +221,33 @@ func updateTerminalFlights(targetTerminal Terminal) (err error) {
 			break
 		}
 
-		go func(edgePhoto PhotosEdgePhoto, t Terminal) {
+		//If image is too old, ignore
+		if !isPhotoRecent(photos[photoIndex]) {
+			displayMessageForTerminal(targetTerminal, photos[photoIndex].Id+" over 24 hours old.")
+			sem.Release(1)
+			continue
+		}
+
+		go func(photo SourcePhoto, t Terminal) {
 			defer sem.Release(1)
 			var flightsFoundInPhoto int
 			var err error
-			if flightsFoundInPhoto, err = processPhotoNode(edgePhoto, t); err != nil {
+
+			//Create job to report photo processing status
+			var job *PhotoJob
+			if job, err = newPhotoJob(t, photo); err != nil {
+				displayErrorForTerminal(t, err.Error())
+				errorCount++
+				return
+			}
+
+			if flightsFoundInPhoto, err = processPhotoNode(photo, source, t, job); err != nil {
 				displayErrorForTerminal(t, err.Error())
 				errorCount++
 			}
 
 			flightsFound += flightsFoundInPhoto
-		}(photosEdge.Data[photoIndex], targetTerminal)
+		}(photos[photoIndex], targetTerminal)
 	}
 
 	if err := sem.Acquire(ctx, int64(maxWorkers)); err != nil {
@@ -327,7 +332,7 @@ func find72HrAlbumId(t Terminal) (albumId string, err error) {
 
 		if regexResult := Hr72Regex.FindStringSubmatch(album.Name); regexResult != nil {
 			var albumUpdatedTime time.Time
-			layout := "2006-01-02T15:04:05-0700"
+			layout := GRAPH_TIME_LAYOUT
 
 			log.Println(album.Name)
 
@@ -408,53 +413,80 @@ func getPhotosEdge(id string) (photosEdge PhotosEdge, err error) {
 	return
 }
 
-//Download, save, OCR a photo from Photos Edge
-func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (flightsFound int, err error) {
+//Check if photo created within PHOTO_SOURCE_MAX_AGE (made recently?)
+func isPhotoRecent(photo SourcePhoto) bool {
+	return time.Since(photo.CreatedTime) <= PHOTO_SOURCE_MAX_AGE
+}
+
+//Download, save, OCR a photo from PhotoSource
+//Processing state and results are recorded in job if job is not nil.
+func processPhotoNode(photo SourcePhoto, source PhotoSource, targetTerminal Terminal, job *PhotoJob) (flightsFound int, err error) {
+
+	//Record any error as job failure
+	defer func() {
+		if err != nil {
+			job.setState(JOB_STATE_FAILED, err)
+		}
+	}()
 
 	//Check if photo created within X timeframe (made recently?)
-	var photoUpdatedTime time.Time
-	//http://stackoverflow.com/questions/24401901/time-parse-why-does-golang-parses-the-time-incorrectly
-	layout := "2006-01-02T15:04:05-0700"
-	if photoUpdatedTime, err = time.Parse(layout, edgePhoto.UpdatedTime); err != nil{
-		return
-	}
+	photoUpdatedTime := photo.CreatedTime
 
 	//log.Println(photoUpdatedTime)
 
 	//If image is too old, ignore
-	if time.Since(photoUpdatedTime) > time.Hour*24 {
-		displayMessageForTerminal(targetTerminal, edgePhoto.Id+" over 24 hours old.")
+	if !isPhotoRecent(photo) {
+		displayMessageForTerminal(targetTerminal, photo.Id+" over 24 hours old.")
+		job.setState(JOB_STATE_SKIPPED, fmt.Errorf("Photo created %v is older than %v.", photoUpdatedTime, PHOTO_SOURCE_MAX_AGE))
 		return
 	}
 
+	job.setState(JOB_STATE_OCR, nil)
+
 	incrementPhotosFound()
 
 	//displayMessageForTerminal(targetTerminal, fmt.Sprintf("Downloading recent photo %v",photoIndex+1))
 
-	var saveTypes []SaveImageType
-	saveTypes = []SaveImageType{SAVE_IMAGE_TRAINING, SAVE_IMAGE_TRAINING_PROCESSED_BLACK, SAVE_IMAGE_TRAINING_PROCESSED_WHITE}
+	//Image variants fed to OCR for this terminal
+	var variants []ImageVariant
+	if variants, err = imageVariantsForTerminal(targetTerminal); err != nil {
+		return
+	}
 
 	tmpSlide := Slide{
-		SaveType:      saveTypes[0],
+		SaveType:      SAVE_IMAGE_TRAINING,
 		Terminal:      targetTerminal,
-		FBNodeId:      edgePhoto.Id,
+		FBNodeId:      photo.Id,
 		FBCreatedTime: time.Time{}}
 
-	//Request Photo node for slide
-	var photoNode PhotoNode
-
 	//Only do network operations to fetch image if not in DEBUG_MANUAL_IMAGE_FILE_TARGET true mode
 	if DEBUG_MANUAL_IMAGE_FILE_TARGET {
 
 	} else {
-		if photoNode, err = getPhotoNodeForSlide(tmpSlide); err != nil {
+		//Fetch image for photo from PhotoSource
+		var imageData []byte
+		if imageData, err = source.PhotoImage(photo); err != nil {
+			return
+		}
+
+		//Save image for slide
+		if err = saveImageForSlide(imageData, &tmpSlide); err != nil {
 			return
 		}
+	}
 
-		//Download and Save Image for Photo node
-		if err = downloadAndSaveImageForPhotoNode(photoNode, &tmpSlide); err != nil {
+	//Decode photo once. Variants and crops are created from the image in memory.
+	//Correct perspective and skew of photos of screens before creating variants
+	transform := identityImageTransform()
+	if *rectifyPhotos || targetTerminal.Rectify {
+		if tmpSlide.Image, transform, err = rectifySlideImage(tmpSlide); err != nil {
 			return
 		}
+		if !transform.isIdentity() {
+			displayMessageForTerminal(targetTerminal, fmt.Sprintf("%v rectified screen %v skew %.2f degrees", photo.Id, transform.Quadrilateral, transform.SkewAngle))
+		}
+	} else if tmpSlide.Image, err = readImageFile(photoPath(tmpSlide)); err != nil {
+		return
 	}
 
 	//DEBUG
@@ -463,13 +495,14 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 
 	var slides []Slide
 	slides = make([]Slide, 0)
-	for _, currentSaveType := range saveTypes {
+	for _, variant := range variants {
 		var newSlide Slide
-		newSlide.SaveType = currentSaveType
+		newSlide.SaveType = variant.SaveType
 		newSlide.Extension = tmpSlide.Extension
 		newSlide.Terminal = targetTerminal
-		newSlide.FBNodeId = edgePhoto.Id
+		newSlide.FBNodeId = photo.Id
 		newSlide.FBCreatedTime = photoUpdatedTime
+		newSlide.Transform = transform
 
 		//Manual slide control
 		//newSlide.Extension = "jpeg"
@@ -477,11 +510,13 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 		//newSlide.FBNodeId = "1600297960039607"
 		//newSlide.FBNodeId = "1600298003372936"
 
-		//create processed image in imagemagick IF slide created is not original slide
-		if currentSaveType != SAVE_IMAGE_TRAINING {
-			if err = runImageMagickColorProcess(SAVE_IMAGE_TRAINING, newSlide); err != nil {
+		//create processed image with imageProcessor IF slide created is not original slide
+		if variant.SaveType != SAVE_IMAGE_TRAINING {
+			if err = runImageColorProcess(tmpSlide, &newSlide); err != nil {
 				return
 			}
+		} else {
+			newSlide.Image = tmpSlide.Image
 		}
 
 		if err = doOCRForSlide(&newSlide, OCR_WHITELIST_NORMAL); err != nil {
@@ -491,19 +526,98 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 		slides = append(slides, newSlide)
 	}
 
-	//Find date displayed in photo. Pick best date from slides.
-	var slideDate time.Time
-	if slideDate, err = findDateOfPhotoNodeSlides(slides); err != nil {
+	job.setState(JOB_STATE_PARSING, nil)
+
+	//Parse flights from OCR text of slides
+	var finalFlights []Flight
+	var slideDays []time.Time
+	var learnedLabels map[string]image.Rectangle
+	if finalFlights, slideDays, learnedLabels, err = parseFlightsFromSlides(slides, job); err != nil {
 		return
 	}
 
-	//Display found date
-	displayMessageForTerminal(slides[0].Terminal, fmt.Sprintf("%v found date for photo node \u001b[1m\u001b[31m%v\u001b[0m", slides[0].FBNodeId, slideDate.Format("02 Jan 2006 -0700")))
+	job.setFlights(finalFlights)
+
+	//DEBUG Only update database if not DEBUG_MANUAL_IMAGE_FILE_TARGET true
+	if DEBUG_MANUAL_IMAGE_FILE_TARGET {
+
+	} else {
+		//Delete previous cancelled/duplicate flights for terminal for each day on slide from database
+		for _, day := range slideDays {
+			if err = deleteFlightsFromTableForDayForOriginTerminal(FLIGHTS_72HR_TABLE, day, slides[0].Terminal); err != nil {
+				return
+			}
+		}
+		if err = insertFlightsIntoTable(FLIGHTS_72HR_TABLE, finalFlights); err != nil {
+			return
+		}
+		if err = insertFlightLegsIntoTable(FLIGHT_LEGS_TABLE, finalFlights); err != nil {
+			return
+		}
 
-	//Get dest bbox
-	//TODO: Find bounds of destination column and crop to get better OCR results.
+		//Learn label positions found by OCR for photos with missing or misread labels later
+		if err = learnLayoutProfileFromSlides(slides, learnedLabels, finalFlights); err != nil {
+			log.Println("Learn layout profile error: ", err)
+			err = nil
+		}
+	}
+	
+
+	flightsFound = len(finalFlights)
+	incrementPhotosProcessed()
+	job.setState(JOB_STATE_STORED, nil)
+	/*
+		//Debugging print slides array
+		log.Printf("len(saveTypes) %v", len(saveTypes))
+		log.Printf("len(slides) %v", len(slides))
+		for _, s := range slides {
+			log.Printf("slide type %v", s.saveType)
+		}
+	*/
+
+	return
+}
+
+//Parse flights from OCR'd image variant slides of a photo. Slide date found is recorded in job if job is not nil.
+//days are the slide date and day section header dates of the slide. learnedLabels are label bboxes found by OCR to add to the terminal layout profile.
+func parseFlightsFromSlides(slides []Slide, job *PhotoJob) (flights []Flight, days []time.Time, learnedLabels map[string]image.Rectangle, err error) {
+	//Measure text line height to scale layout thresholds to photo resolution
+	var lineHeight int
+	if lineHeight, err = measureTextLineHeight(slides); err != nil {
+		return
+	}
+
+	//Use terminal slide layout template if slide labels are where template expects them
+	var layout *SlideLayout
+	if slides[0].Terminal.Layout != nil {
+		var layoutMatched bool
+		if layoutMatched, err = matchSlideLayout(*slides[0].Terminal.Layout, slides); err != nil {
+			return
+		}
+		if layoutMatched {
+			layout = slides[0].Terminal.Layout
+			displayMessageForTerminal(slides[0].Terminal, fmt.Sprintf("%v matched slide layout template.", slides[0].FBNodeId))
+		} else {
+			displayMessageForTerminal(slides[0].Terminal, fmt.Sprintf("%v did not match slide layout template. Using generic layout detection.", slides[0].FBNodeId))
+		}
+	}
+
+	//Find date displayed in photo. Pick best date from slides. Only search template header if layout matched.
+	var headerRegion image.Rectangle
+	if layout != nil {
+		if headerRegion, err = layout.headerRectForSlides(slides); err != nil {
+			return
+		}
+	}
+	var slideDate time.Time
+	if slideDate, err = findDateOfPhotoNodeSlides(slides, headerRegion); err != nil {
+		return
+	}
+
+	//Get dest bbox. Use learned terminal profile if label not found.
 	var destLabelBBox image.Rectangle
-	if destLabelBBox, err = findLabelBoundsOfPhotoNodeSlides(slides, KEYWORD_DESTINATION); err != nil {
+	var destLabelLearned bool
+	if destLabelBBox, destLabelLearned, err = findLabelBoundsOrLearnedOfPhotoNodeSlides(slides, KEYWORD_DESTINATION); err != nil {
 		return
 	}
 
@@ -516,10 +630,58 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 	if destLabelValid, err = slides[0].isYCoordinateWithinHeightPercentage(destLabelBBox.Min.Y, DESTINATION_TEXT_VERTICAL_THRESHOLD); err != nil {
 		return
 	}
+	//Label too low may be misread. Use learned terminal profile instead if available.
+	if !destLabelValid && !destLabelLearned {
+		var learnedBBox image.Rectangle
+		var ok bool
+		if learnedBBox, ok, err = findLearnedLabelBoundsOfPhotoNodeSlides(slides, KEYWORD_DESTINATION); err != nil {
+			return
+		}
+		if ok {
+			displayMessageForTerminal(slides[0].Terminal, "Using learned "+KEYWORD_DESTINATION+" label position.")
+			destLabelBBox = learnedBBox
+			destLabelLearned = true
+			if destLabelValid, err = slides[0].isYCoordinateWithinHeightPercentage(destLabelBBox.Min.Y, DESTINATION_TEXT_VERTICAL_THRESHOLD); err != nil {
+				return
+			}
+		}
+	}
 	if !destLabelValid { //
 		destLabelBBox.Min.Y = 0
 	}
 
+	//Detect table rows and columns so text can be linked by row. Use template columns if layout matched.
+	var grid TableGrid
+	if layout != nil {
+		if grid, err = layout.tableGridForSlides(slides, lineHeight); err != nil {
+			return
+		}
+	} else {
+		if grid, err = detectTableGridOfPhotoNodeSlides(slides, destLabelBBox, lineHeight); err != nil {
+			return
+		}
+	}
+
+	//Find date header of each day section of multi-day slides in header and section band rows. Use topmost header if slide date not found.
+	var dateHeaders []DateHeader
+	if dateHeaders, err = findDateHeadersOfPhotoNodeSlides(slides, destLabelBBox.Min.Y, grid); err != nil {
+		return
+	}
+	if slideDate.Equal(time.Time{}) && len(dateHeaders) > 0 {
+		slideDate = dateHeaders[0].Date
+	}
+	job.setSlideDate(slideDate)
+
+	//Display found date
+	displayMessageForTerminal(slides[0].Terminal, fmt.Sprintf("%v found date for photo node \u001b[1m\u001b[31m%v\u001b[0m", slides[0].FBNodeId, slideDate.Format("02 Jan 2006 -0700")))
+	if len(dateHeaders) > 1 {
+		var headerDates []string
+		for _, h := range dateHeaders {
+			headerDates = append(headerDates, h.Date.Format("02 Jan 2006"))
+		}
+		displayMessageForTerminal(slides[0].Terminal, fmt.Sprintf("%v found date headers %v", slides[0].FBNodeId, strings.Join(headerDates, ", ")))
+	}
+
 	if rollCalls, rollCallsNoBBox, err = findRollCallTimesFromSlides(slides, slideDate, destLabelBBox.Min.Y); err != nil {
 		return
 	}
@@ -530,9 +692,18 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 		fmt.Println("rollcalls w/o bbox", rollCallsNoBBox)
 	}
 
-	//Get seats bbox
+	//Get seats bbox. Use template seats column above table top if layout matched, or learned terminal profile if label not found.
 	var seatsLabelBBox image.Rectangle
-	if seatsLabelBBox, err = findLabelBoundsOfPhotoNodeSlides(slides, KEYWORD_SEATS); err != nil {
+	var seatsLabelLearned bool
+	var seatsColumnRect image.Rectangle
+	if layout != nil {
+		if seatsColumnRect, err = layout.columnRectForSlides(slides, LAYOUT_COLUMN_SEATS); err != nil {
+			return
+		}
+	}
+	if !seatsColumnRect.Empty() {
+		seatsLabelBBox = image.Rect(seatsColumnRect.Min.X, seatsColumnRect.Min.Y, seatsColumnRect.Max.X, seatsColumnRect.Min.Y)
+	} else if seatsLabelBBox, seatsLabelLearned, err = findLabelBoundsOrLearnedOfPhotoNodeSlides(slides, KEYWORD_SEATS); err != nil {
 		return
 	}
 
@@ -550,7 +721,41 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 
 	deleteTerminalFromDestArray(&destinations, slides[0].Terminal)
 	deleteLowDestsFromDestArray(&destinations, slides[0])
+
+	//Drop destinations and roll calls outside their columns
+	if layout != nil {
+		var destColumnRect, rollCallColumnRect image.Rectangle
+		if destColumnRect, err = layout.columnRectForSlides(slides, LAYOUT_COLUMN_DESTINATION); err != nil {
+			return
+		}
+		if rollCallColumnRect, err = layout.columnRectForSlides(slides, LAYOUT_COLUMN_ROLLCALL); err != nil {
+			return
+		}
+		deleteDestsOutsideColumnFromDestArray(&destinations, grid, grid.cellForRect(destColumnRect).Column)
+		if !rollCallColumnRect.Empty() {
+			deleteRCsOutsideColumnFromRCArray(&rollCalls, grid, grid.cellForRect(rollCallColumnRect).Column)
+		}
+	} else if destLabelValid && grid.hasColumns() {
+		deleteDestsOutsideColumnFromDestArray(&destinations, grid, grid.cellForRect(destLabelBBox).Column)
+	}
 	
+	//Date each roll call with the day section header above it
+	assignDateHeadersToRollCalls(dateHeaders, rollCalls)
+
+	//Interpret roll call times as Zulu or local from time suffixes and column labels
+	assignTimeZoneLabelsToRollCalls(findTimeZoneLabelsOfPhotoNodeSlides(slides), rollCalls)
+	deleteZuluDuplicatesFromRCArray(&rollCalls)
+
+	//Separate show and departure times from roll calls by column label. Labels are only read from header row.
+	var headerBBox image.Rectangle
+	if destLabelValid {
+		headerBBox = destLabelBBox
+	}
+	assignTimeColumnLabelsToRollCalls(findTimeColumnLabelsOfPhotoNodeSlides(slides, headerBBox, lineHeight), rollCalls)
+	var otherTimes []RollCall
+	rollCalls, otherTimes = splitRollCallsByKind(rollCalls)
+	linkTimesToRollCalls(grid, rollCalls, otherTimes, lineHeight)
+
 	//Print roll calls object. 
 		fmt.Println("found rcs in all slides")
 		for _, rc := range rollCalls {
@@ -565,34 +770,47 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 		//return
 	
 
-	//Find vertically closest Destination for every RollCall
-	linkRollCallsToNearestDestinations(rollCalls, destinations)
-
-	/*
-		fmt.Println("After nearest rc to dest link")
-		for _, d := range destinations {
-			log.Println(d)
-			if d.LinkedRollCall != nil {
-				log.Println(*(d.LinkedRollCall))
+	if grid.hasRows() {
+		//Link RollCall, SeatsAvailable and Destinations in the same table row
+		linkByGridRows(grid, rollCalls, destinations, seatsAvailable)
+	} else {
+		//Find vertically closest Destination for every RollCall
+		linkRollCallsToNearestDestinations(rollCalls, destinations, lineHeight)
+
+		/*
+			fmt.Println("After nearest rc to dest link")
+			for _, d := range destinations {
+				log.Println(d)
+				if d.LinkedRollCall != nil {
+					log.Println(*(d.LinkedRollCall))
+				}
 			}
-		}
-	*/
+		*/
 
-	//Link SeatsAvailable with RollCalls on same line
-	linkRollCallsToNearestSeatsAvailable(rollCalls, seatsAvailable)
+		//Link SeatsAvailable with RollCalls on same line
+		linkRollCallsToNearestSeatsAvailable(rollCalls, seatsAvailable, lineHeight)
 
-	/*
-		fmt.Println("After link seats")
-		for _, rc := range rollCalls {
-			log.Println(rc)
-			if rc.LinkedSeatsAvailable != nil {
-				log.Println(*(rc.LinkedSeatsAvailable))
+		/*
+			fmt.Println("After link seats")
+			for _, rc := range rollCalls {
+				log.Println(rc)
+				if rc.LinkedSeatsAvailable != nil {
+					log.Println(*(rc.LinkedSeatsAvailable))
+				}
 			}
-		}
-	*/
+		*/
 
-	//Find vertically closest Destination for every SeatsAvailable
-	linkDestinationsToNearestSeatsAvailable(destinations, seatsAvailable)
+		//Find vertically closest Destination for every SeatsAvailable
+		linkDestinationsToNearestSeatsAvailable(destinations, seatsAvailable, lineHeight)
+	}
+
+	//Find cancelled, delayed and full flights from status words, red text and struck through text in each Destination row
+	if err = detectFlightStatusOfDestinations(slides, destinations, findFlightStatusMarkersOfPhotoNodeSlides(slides, destLabelBBox.Min.Y), grid, lineHeight); err != nil {
+		return
+	}
+
+	//Find aircraft or mission listed in each Destination row
+	assignAircraftToDestinations(destinations, findAircraftMarkersOfPhotoNodeSlides(slides, destLabelBBox.Min.Y), grid, lineHeight)
 
 	//Create array of individual Grouping for each Destination to pass into combine Destinations to Groupings stage
 	var destinationGroupings []Grouping
@@ -613,7 +831,7 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 			fmt.Printf("%v - ", (*dg.LinkedRollCall).Time.Format("\u001b[1m\u001b[35m‣ 02JAN2006 1504 MST -0700\u001b[0m"))
 
 			if (*dg.LinkedRollCall).LinkedSeatsAvailable != nil {
-				fmt.Printf("%v%v\n", (*(*dg.LinkedRollCall).LinkedSeatsAvailable).Number, (*(*dg.LinkedRollCall).LinkedSeatsAvailable).Letter)
+				fmt.Printf("%v (%v)\n", (*(*dg.LinkedRollCall).LinkedSeatsAvailable).Spelling, (*(*dg.LinkedRollCall).LinkedSeatsAvailable).Code)
 			} else {
 				fmt.Println("No seat text found.")
 			}
@@ -632,6 +850,9 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 	//Convert Destinations to Flight struct and add
 	var finalFlights []Flight
 	for dgIndex, _ := range destinationGroupings {
+		//Ordered legs for Destinations listed as stops on the same line
+		groupingLegs := findLegsOfGroupingDestinations(slides[0].Terminal.Title, destinationGroupings[dgIndex])
+
 		//Link RollCall to all Destinations in Grouping (if RollCall linked)
 		//Add to each Destination final flights list
 		for dIndex, _ := range destinationGroupings[dgIndex].Destinations {
@@ -653,8 +874,10 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 
 			//Set Flight.UnknownRollCallDate if applicable
 			//Check if date was NOT found by comparing to "blank" time.Time
+			//Roll calls dated by a day section header have a known date
 			var unknownRCDate bool
-			if slideDate.Equal(time.Time{}) {
+			linkedRollCall := destinationGroupings[dgIndex].Destinations[dIndex].LinkedRollCall
+			if slideDate.Equal(time.Time{}) && (linkedRollCall == nil || !(*linkedRollCall).DateFromHeader) {
 				unknownRCDate = true
 			} else {
 				incrementPhotosFoundDateHeader()
@@ -666,18 +889,32 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 				Destination: destinationGroupings[dgIndex].Destinations[dIndex].TerminalTitle,
 
 				UnknownRollCallDate: unknownRCDate,
+				Status:              FLIGHT_STATUS_SCHEDULED,
+				Aircraft:            destinationGroupings[dgIndex].Destinations[dIndex].Aircraft,
+				Legs:                groupingLegs[dIndex],
 				PhotoSource:         slides[0].FBNodeId,
 				SourceDate:          slides[0].FBCreatedTime}
 
+			if status := destinationGroupings[dgIndex].Destinations[dIndex].Status; len(status) > 0 {
+				tmpFlight.Status = status
+				tmpFlight.Cancelled = status == FLIGHT_STATUS_CANCELLED
+			}
+
 			if destinationGroupings[dgIndex].Destinations[dIndex].LinkedRollCall != nil {
-				tmpFlight.RollCall = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedRollCall).Time
+				tmpFlight.setTimesFromRollCall(*destinationGroupings[dgIndex].Destinations[dIndex].LinkedRollCall)
 			}
 
 			if destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable != nil {
 				tmpFlight.SeatCount = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable).Number
 				tmpFlight.SeatType = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable).Letter
+				tmpFlight.SeatCode = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable).Code
+				tmpFlight.SeatMin = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable).Min
+				tmpFlight.SeatMax = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable).Max
+				tmpFlight.FirmSeatCount = (*destinationGroupings[dgIndex].Destinations[dIndex].LinkedSeatsAvailable).Firm
 			}
 
+			tmpFlight.scoreConfidence(slides, destinationGroupings[dgIndex].Destinations[dIndex], !unknownRCDate, lineHeight)
+
 			finalFlights = append(finalFlights, tmpFlight)
 		}
 	}
@@ -690,39 +927,32 @@ func processPhotoNode(edgePhoto PhotosEdgePhoto, targetTerminal Terminal) (fligh
 		}
 	*/
 
-	//DEBUG Only update database if not DEBUG_MANUAL_IMAGE_FILE_TARGET true
-	if DEBUG_MANUAL_IMAGE_FILE_TARGET {
-
-	} else {
-		//Delete previous cancelled/duplicate flights for terminal for day from database
-		if err = deleteFlightsFromTableForDayForOriginTerminal(FLIGHTS_72HR_TABLE, slideDate, slides[0].Terminal); err != nil {
-			return
-		}
-		if err = insertFlightsIntoTable(FLIGHTS_72HR_TABLE, finalFlights); err != nil {
-			return
+	//Days on slide to replace stored flights of
+	days = []time.Time{slideDate}
+	for _, h := range dateHeaders {
+		if !h.Date.Equal(slideDate) {
+			days = append(days, h.Date)
 		}
 	}
-	
 
-	flightsFound = len(finalFlights)
-	incrementPhotosProcessed()
-	/*
-		//Debugging print slides array
-		log.Printf("len(saveTypes) %v", len(saveTypes))
-		log.Printf("len(slides) %v", len(slides))
-		for _, s := range slides {
-			log.Printf("slide type %v", s.saveType)
-		}
-	*/
+	//Label positions found by OCR
+	learnedLabels = make(map[string]image.Rectangle)
+	if destLabelValid && !destLabelLearned {
+		learnedLabels[KEYWORD_DESTINATION] = destLabelBBox
+	}
+	if seatsColumnRect.Empty() && !seatsLabelLearned {
+		learnedLabels[KEYWORD_SEATS] = seatsLabelBBox
+	}
 
+	flights = finalFlights
 	return
 }
 
-//Request Photo node for Slide (info from Photo edge).
-func getPhotoNodeForSlide(sReference Slide) (photoNode PhotoNode, err error) {
+//Request Photo node for photo id (info from Photo edge).
+func getPhotoNode(id string) (photoNode PhotoNode, err error) {
 	//Create request url and parameters
 	apiUrl := GRAPH_API_URL
-	resource := fmt.Sprintf("%v/%v", GRAPH_API_VERSION, sReference.FBNodeId)
+	resource := fmt.Sprintf("%v/%v", GRAPH_API_VERSION, id)
 	data := url.Values{}
 	data.Add(GRAPH_FIELDS_KEY, GRAPH_FIELD_IMAGES_KEY)
 	data.Add(GRAPH_ACCESS_TOKEN_KEY, GRAPH_ACCESS_TOKEN)
@@ -769,35 +999,14 @@ func getPhotoNodeForSlide(sReference Slide) (photoNode PhotoNode, err error) {
 	return
 }
 
-//Download first image for Photo node to IMAGE_TMP_DIRECTORY and Save in location for Slide.
-//Sets Extension for Slide based on http.DetectContentType()
-func downloadAndSaveImageForPhotoNode(photoNode PhotoNode, sReference *Slide) (err error) {
+//Download first image for Photo node.
+func downloadImageForPhotoNode(photoNode PhotoNode, id string) (imageData []byte, err error) {
 
 	if len(photoNode.Images) == 0 {
-		err = errors.New(fmt.Sprintf("PhotoNode %v %v has no images.", (*sReference).Terminal.Title, (*sReference).FBNodeId))
+		err = fmt.Errorf("PhotoNode %v has no images.", id)
 		return
 	}
 
-	//Create tmp directory if needed
-	var exist bool
-	if exist, err = exists(IMAGE_TMP_DIRECTORY); exist == false || err != nil {
-		if err != nil {
-			return
-		}
-		if err = os.Mkdir(IMAGE_TMP_DIRECTORY, os.ModePerm); err != nil {
-			return
-		}
-		return
-	}
-
-	//Create file handle to tmp photo path
-	tmpFilepath := fmt.Sprintf("%v/%v", IMAGE_TMP_DIRECTORY, (*sReference).FBNodeId)
-	var tmpFile *os.File
-	if tmpFile, err = os.Create(tmpFilepath); err != nil {
-		return
-	}
-	defer tmpFile.Close()
-
 	//Create request
 	var req *http.Request
 	var client *http.Client
@@ -813,27 +1022,38 @@ func downloadAndSaveImageForPhotoNode(photoNode PhotoNode, sReference *Slide) (e
 		return
 	}
 
-	//Read response body into os.File
+	//Read response body into []byte
 	defer resp.Body.Close()
-	if _, err = io.Copy(tmpFile, resp.Body); err != nil {
+	if imageData, err = ioutil.ReadAll(resp.Body); err != nil {
 		return
 	}
+	return
+}
 
-	//Open tmp file for reading and read first 512 bytes for http.DetectContentType()
+//Save image data to IMAGE_TMP_DIRECTORY and copy to location for Slide.
+//Sets Extension for Slide based on http.DetectContentType()
+func saveImageForSlide(imageData []byte, sReference *Slide) (err error) {
 
-	var fileHeader []byte
-	fileHeader = make([]byte, 512)
-	if tmpFile, err = os.Open(tmpFilepath); err != nil {
+	if len(imageData) == 0 {
+		err = fmt.Errorf("Image for %v %v is empty.", (*sReference).Terminal.Title, (*sReference).FBNodeId)
 		return
 	}
 
-	defer tmpFile.Close()
-	var length int
-	if length, err = tmpFile.Read(fileHeader); err != nil && err != io.EOF {
+	//Create tmp directory if needed
+	if err = createImageDirectories(IMAGE_TMP_DIRECTORY); err != nil {
 		return
 	}
-	if length < len(fileHeader) {
-		fileHeader = fileHeader[:length]
+
+	//Write image to tmp photo path
+	tmpFilepath := fmt.Sprintf("%v/%v", IMAGE_TMP_DIRECTORY, (*sReference).FBNodeId)
+	if err = ioutil.WriteFile(tmpFilepath, imageData, 0644); err != nil {
+		return
+	}
+
+	//Detect content type from first 512 bytes
+	fileHeader := imageData
+	if len(fileHeader) > 512 {
+		fileHeader = fileHeader[:512]
 	}
 
 	var detectedContentType string
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//Recorded OCR fixtures of testdata/dyess_afb_pax_term_schedule.png
+const (
+	fixtureTerminalTitle = "Dyess AFB Pax Term"
+	fixturePhotoId       = "schedule"
+	fixtureImage         = "testdata/dyess_afb_pax_term_schedule.png"
+	fixtureOCRDirectory  = "testdata/ocr_fixtures"
+)
+
+//Return terminal with title from terminal file.
+func terminalForTitle(t *testing.T, title string) Terminal {
+	terminals, err := readTerminalArrayFromFiles(TERMINAL_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, terminal := range terminals {
+		if terminal.Title == title {
+			return terminal
+		}
+	}
+	t.Fatalf("Terminal %v not in %v", title, TERMINAL_FILE)
+	return Terminal{}
+}
+
+//Call fn with OCR'd slide of schedule image. OCR results are replayed from fixtureDirectory.
+//Slide images are written to a temporary working directory so the repo is not modified.
+func withFixtureScheduleSlide(t *testing.T, imagePath string, fixtureDirectory string, fn func(slide Slide)) {
+	terminal := terminalForTitle(t, fixtureTerminalTitle)
+
+	var err error
+	if imagePath, err = filepath.Abs(imagePath); err != nil {
+		t.Fatal(err)
+	}
+	if fixtureDirectory, err = filepath.Abs(fixtureDirectory); err != nil {
+		t.Fatal(err)
+	}
+	var keywordFiles []string
+	for _, filename := range []string{TERMINAL_FILE, LOCATION_KEYWORDS_FILE} {
+		var keywordFile string
+		if keywordFile, err = filepath.Abs(filename); err != nil {
+			t.Fatal(err)
+		}
+		keywordFiles = append(keywordFiles, keywordFile)
+	}
+
+	//Work in temporary directory with keyword files
+	var workingDirectory, previousDirectory string
+	if previousDirectory, err = os.Getwd(); err != nil {
+		t.Fatal(err)
+	}
+	if workingDirectory, err = ioutil.TempDir("", "spacea_parse"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workingDirectory)
+	if err = os.Chdir(workingDirectory); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(previousDirectory)
+	for _, keywordFile := range keywordFiles {
+		if err = os.Symlink(keywordFile, filepath.Base(keywordFile)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previousEngine, previousProcessor := ocrEngine, imageProcessor
+	ocrEngine = replayOCREngine{Directory: fixtureDirectory}
+	imageProcessor = goImageProcessor{}
+	defer func() { ocrEngine, imageProcessor = previousEngine, previousProcessor }()
+
+	if err = acquireFuzzyModels(); err != nil {
+		t.Fatal(err)
+	}
+	defer releaseFuzzyModels()
+
+	//Slide of original image as saved by processPhotoNode
+	slide := Slide{
+		SaveType:      SAVE_IMAGE_TRAINING,
+		Extension:     "png",
+		Terminal:      terminal,
+		FBNodeId:      fixturePhotoId,
+		FBCreatedTime: time.Date(2019, time.March, 28, 6, 0, 0, 0, terminal.Timezone)}
+
+	var imageData []byte
+	if imageData, err = ioutil.ReadFile(imagePath); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(photoPath(slide)), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(photoPath(slide), imageData, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = doOCRForSlide(&slide, OCR_WHITELIST_NORMAL); err != nil {
+		t.Fatal(err)
+	}
+	fn(slide)
+}
+
+//Parse flights from schedule image with OCR results replayed from fixtureDirectory.
+func parseFixtureSchedule(t *testing.T, imagePath string, fixtureDirectory string) (flights []Flight) {
+	withFixtureScheduleSlide(t, imagePath, fixtureDirectory, func(slide Slide) {
+		var err error
+		if flights, _, _, err = parseFlightsFromSlides([]Slide{slide}, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	return
+}
+
+//Flights of the fixture schedule should be parsed with roll call and seats of their row.
+func TestParseFlightsFromSlidesReplay(t *testing.T) {
+	flights := parseFixtureSchedule(t, fixtureImage, fixtureOCRDirectory)
+
+	expected := []struct {
+		Destination string
+		RollCall    string
+		SeatCode    string
+		SeatMax     int
+	}{
+		{"Ramstein AB, Germany", "1015", SEAT_CODE_FIRM, 15},
+		{"NS Rota, Spain", "1330", SEAT_CODE_TENTATIVE, 30},
+		{"Travis AFB, California", "2045", SEAT_CODE_TBD, 0}}
+
+	if len(flights) != len(expected) {
+		t.Fatalf("Found %v flights, expected %v: %v", len(flights), len(expected), flights)
+	}
+	for _, e := range expected {
+		var found bool
+		for _, f := range flights {
+			if f.Destination != e.Destination {
+				continue
+			}
+			found = true
+			if rollCall := f.RollCall.Format("1504"); rollCall != e.RollCall {
+				t.Errorf("%v roll call %v, expected %v", e.Destination, rollCall, e.RollCall)
+			}
+			if f.SeatCode != e.SeatCode || f.SeatMax != e.SeatMax {
+				t.Errorf("%v seats %v %v, expected %v %v", e.Destination, f.SeatCode, f.SeatMax, e.SeatCode, e.SeatMax)
+			}
+			if f.Origin != fixtureTerminalTitle {
+				t.Errorf("%v origin %v, expected %v", e.Destination, f.Origin, fixtureTerminalTitle)
+			}
+		}
+		if !found {
+			t.Errorf("No flight to %v in %v", e.Destination, flights)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"math"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
@@ -68,22 +69,14 @@ func copyFileContents(src, dst string) (err error) {
 //Return local photo path for SaveImageType
 func photoPath(slide Slide) (photoFilename string) {
 	var photoDirectory string
-	switch slide.SaveType {
-	case SAVE_IMAGE_TRAINING:
-		photoDirectory = IMAGE_TRAINING_DIRECTORY
-		//Set special initial DEBUG training directory if training manual file
-		if DEBUG_MANUAL_IMAGE_FILE_TARGET {
-			photoDirectory = DEBUG_MANUAL_IMAGE_FILE_TARGET_TRAINING_DIRECTORY
-		}
-		break
-	case SAVE_IMAGE_TRAINING_PROCESSED_BLACK:
-		photoDirectory = IMAGE_TRAINING_PROCESSED_DIRECTORY_BLACK
-		break
-	case SAVE_IMAGE_TRAINING_PROCESSED_WHITE:
-		photoDirectory = IMAGE_TRAINING_PROCESSED_DIRECTORY_WHITE
-		break
-	default:
-		log.Println("Unknown save type ", slide.SaveType)
+	if variant, err := imageVariantForSaveType(slide.SaveType); err != nil {
+		log.Println(err)
+	} else {
+		photoDirectory = variant.Directory
+	}
+	//Set special initial DEBUG training directory if training manual file
+	if slide.SaveType == SAVE_IMAGE_TRAINING && DEBUG_MANUAL_IMAGE_FILE_TARGET {
+		photoDirectory = DEBUG_MANUAL_IMAGE_FILE_TARGET_TRAINING_DIRECTORY
 	}
 
 	var suffix string
@@ -218,18 +211,32 @@ func getVerticalDistance(bbox1 image.Rectangle, bbox2 image.Rectangle) (vertical
 }
 
 func (sReference Slide) getImageConfig() (im image.Config, err error) {
-	//find original dimensions
-	originalSavePath := photoPath(sReference)
-	var reader *os.File
-	if reader, err = os.Open(originalSavePath); err != nil {
-		return
-	}
+	if sReference.Image != nil {
+		//Image kept in memory
+		bounds := sReference.Image.Bounds()
+		im = image.Config{
+			ColorModel: sReference.Image.ColorModel(),
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy()}
+	} else {
+		//find original dimensions
+		originalSavePath := photoPath(sReference)
+		var reader *os.File
+		if reader, err = os.Open(originalSavePath); err != nil {
+			return
+		}
 
-	//load image
-	defer reader.Close()
-	if im, _, err = image.DecodeConfig(reader); err != nil {
-		return
+		//load image
+		defer reader.Close()
+		if im, _, err = image.DecodeConfig(reader); err != nil {
+			return
+		}
 	}
+
+	//Return dimensions in original image coordinates for scaled variants
+	scale := sReference.imageScale()
+	im.Width /= scale
+	im.Height /= scale
 	return
 }
 
@@ -253,6 +260,44 @@ func sameHorizontalLine(bbox1 image.Rectangle, bbox2 image.Rectangle) (horizonta
 	return
 }
 
+//Return median OCR word height of slides in pixels. Used to express layout thresholds in text line heights.
+//If no words were found, return TEXT_LINE_HEIGHT_DEFAULT_FRACTION of image height.
+func measureTextLineHeight(slides []Slide) (lineHeight int, err error) {
+	var heights []int
+	for _, s := range slides {
+		for _, w := range s.Words {
+			if w.Confidence >= OCR_WORD_CONFIDENCE_THRESHOLD && w.BBox.Dy() > 0 {
+				heights = append(heights, w.BBox.Dy())
+			}
+		}
+	}
+
+	if len(heights) > 0 {
+		sort.Ints(heights)
+		lineHeight = heights[len(heights)/2]
+		return
+	}
+
+	lineHeight = 1
+	if len(slides) == 0 {
+		return
+	}
+
+	var im image.Config
+	if im, err = slides[0].getImageConfig(); err != nil {
+		return
+	}
+	if fallback := int(float64(im.Height) * TEXT_LINE_HEIGHT_DEFAULT_FRACTION); fallback > lineHeight {
+		lineHeight = fallback
+	}
+	return
+}
+
+//Convert distance in text line heights to pixels
+func lineHeightsToPixels(lineHeight int, lineHeights float64) int {
+	return int(math.Floor(float64(lineHeight)*lineHeights + 0.5))
+}
+
 //Return true if Y coordinate is less/greater than a certain percentage of slide image height. True = lteq. False = greater
 func (sReference Slide) isYCoordinateWithinHeightPercentage(yCoord int, maxPercentage float64) (valid bool, err error) {
 	var im image.Config